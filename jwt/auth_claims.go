@@ -0,0 +1,216 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/weloe/token-go/auth"
+	"github.com/weloe/token-go/ctx"
+	"strings"
+)
+
+/* authorization claim key */
+const (
+	ROLES       = "roles"
+	PERMISSIONS = "permissions"
+	// SCOPE OAuth scopes, space-delimited string
+	SCOPE = "scope"
+)
+
+// ScopeManager get OAuth scopes of loginId
+type ScopeManager interface {
+	GetScope(id string) []string
+}
+
+// SetAuth set auth manager. If manager implements auth.RBAC, auth.ACL or ScopeManager,
+// roles, permissions and scopes are embedded in token when token is created
+func (s *StatelessEnforcer) SetAuth(manager interface{}) {
+	s.authManager = manager
+	s.e.SetAuth(manager)
+}
+
+// CheckRole check the token of request has role
+func (s *StatelessEnforcer) CheckRole(ctx ctx.Context, role string) error {
+	return s.CheckRoleAnd(ctx, role)
+}
+
+// CheckRoleAnd check the token of request has all roles
+func (s *StatelessEnforcer) CheckRoleAnd(ctx ctx.Context, roles ...string) error {
+	return s.checkAuthClaims(ctx, ROLES, roles, true, matchStr)
+}
+
+// CheckRoleOr check the token of request has one of roles
+func (s *StatelessEnforcer) CheckRoleOr(ctx ctx.Context, roles ...string) error {
+	return s.checkAuthClaims(ctx, ROLES, roles, false, matchStr)
+}
+
+// CheckPermission check the token of request has permission, granted permission supports wildcard such as user:*
+func (s *StatelessEnforcer) CheckPermission(ctx ctx.Context, permission string) error {
+	return s.CheckPermissionAnd(ctx, permission)
+}
+
+// CheckPermissionAnd check the token of request has all permissions
+func (s *StatelessEnforcer) CheckPermissionAnd(ctx ctx.Context, permissions ...string) error {
+	return s.checkAuthClaims(ctx, PERMISSIONS, permissions, true, matchPermission)
+}
+
+// CheckPermissionOr check the token of request has one of permissions
+func (s *StatelessEnforcer) CheckPermissionOr(ctx ctx.Context, permissions ...string) error {
+	return s.checkAuthClaims(ctx, PERMISSIONS, permissions, false, matchPermission)
+}
+
+// CheckScopeAnd check the token of request has all scopes
+func (s *StatelessEnforcer) CheckScopeAnd(ctx ctx.Context, scopes ...string) error {
+	return s.checkAuthClaims(ctx, SCOPE, scopes, true, matchStr)
+}
+
+// CheckScopeOr check the token of request has one of scopes
+func (s *StatelessEnforcer) CheckScopeOr(ctx ctx.Context, scopes ...string) error {
+	return s.checkAuthClaims(ctx, SCOPE, scopes, false, matchStr)
+}
+
+// GetRolesByToken parse token and get roles
+func (s *StatelessEnforcer) GetRolesByToken(token string) ([]string, error) {
+	return s.getAuthClaimsByToken(token, ROLES)
+}
+
+// GetPermissionsByToken parse token and get permissions
+func (s *StatelessEnforcer) GetPermissionsByToken(token string) ([]string, error) {
+	return s.getAuthClaimsByToken(token, PERMISSIONS)
+}
+
+// GetScopesByToken parse token and get scopes
+func (s *StatelessEnforcer) GetScopesByToken(token string) ([]string, error) {
+	return s.getAuthClaimsByToken(token, SCOPE)
+}
+
+func (s *StatelessEnforcer) getAuthClaimsByToken(token string, key string) ([]string, error) {
+	payloads, err := s.parseToken(token, true)
+	if err != nil {
+		return nil, err
+	}
+	return getAuthClaims(payloads, key), nil
+}
+
+// checkAuthClaims check the claim of request token matches all or one of required values
+func (s *StatelessEnforcer) checkAuthClaims(ctx ctx.Context, key string, required []string, isAnd bool, match func(granted string, required string) bool) error {
+	token := s.GetRequestToken(ctx)
+	if token == "" {
+		return errors.New("token is nil")
+	}
+	payloads, err := s.parseRequestToken(ctx, token)
+	if err != nil {
+		return err
+	}
+	return matchAuthClaims(token, payloads, key, required, isAnd, match)
+}
+
+// addAuthClaims embed roles, permissions and scopes of loginId by auth manager
+func (s *StatelessEnforcer) addAuthClaims(claims jwt.MapClaims, id string) {
+	addAuthClaims(claims, s.authManager, id)
+}
+
+// matchAuthClaims check the claim of verified token matches all or one of required values
+func matchAuthClaims(token string, payloads jwt.MapClaims, key string, required []string, isAnd bool, match func(granted string, required string) bool) error {
+	if len(required) == 0 {
+		return fmt.Errorf("arg %v can not be nil", key)
+	}
+	id, err := getIdFromClaims(token, payloads)
+	if err != nil {
+		return err
+	}
+
+	granted := getAuthClaims(payloads, key)
+	for _, r := range required {
+		ok := hasAuthClaim(granted, r, match)
+		if isAnd && !ok {
+			return fmt.Errorf("id %v doesn't has %v %v", id, key, r)
+		}
+		if !isAnd && ok {
+			return nil
+		}
+	}
+	if !isAnd {
+		return fmt.Errorf("id %v doesn't has any %v of %v", id, key, required)
+	}
+	return nil
+}
+
+// addAuthClaims embed roles, permissions and scopes of loginId by manager
+func addAuthClaims(claims jwt.MapClaims, manager interface{}, id string) {
+	if manager == nil {
+		return
+	}
+	if rbac, ok := manager.(auth.RBAC); ok {
+		if roles := rbac.GetRole(id); len(roles) > 0 {
+			claims[ROLES] = roles
+		}
+	}
+	if acl, ok := manager.(auth.ACL); ok {
+		if permissions := acl.GetPermission(id); len(permissions) > 0 {
+			claims[PERMISSIONS] = permissions
+		}
+	}
+	if scopeManager, ok := manager.(ScopeManager); ok {
+		if scopes := scopeManager.GetScope(id); len(scopes) > 0 {
+			claims[SCOPE] = strings.Join(scopes, " ")
+		}
+	}
+}
+
+// getAuthClaims get string array claim, scope claim is a space-delimited string
+func getAuthClaims(payloads jwt.MapClaims, key string) []string {
+	switch value := payloads[key].(type) {
+	case string:
+		return strings.Fields(value)
+	case []string:
+		return value
+	case []interface{}:
+		result := make([]string, 0, len(value))
+		for _, v := range value {
+			if str, ok := v.(string); ok {
+				result = append(result, str)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+func hasAuthClaim(granted []string, required string, match func(granted string, required string) bool) bool {
+	for _, g := range granted {
+		if match(g, required) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchStr(granted string, required string) bool {
+	return granted == required
+}
+
+// matchPermission segments are separated by ':', '*' matches one segment, trailing '*' matches the rest.
+// For example, user:* matches user:add and user:add:self, *:read matches user:read
+func matchPermission(granted string, required string) bool {
+	if granted == required || granted == "*" {
+		return true
+	}
+	grantedSegments := strings.Split(granted, ":")
+	requiredSegments := strings.Split(required, ":")
+	for i, g := range grantedSegments {
+		if i >= len(requiredSegments) {
+			return false
+		}
+		if g == "*" {
+			if i == len(grantedSegments)-1 {
+				return true
+			}
+			continue
+		}
+		if g != requiredSegments[i] {
+			return false
+		}
+	}
+	return len(grantedSegments) == len(requiredSegments)
+}
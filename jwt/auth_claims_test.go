@@ -0,0 +1,102 @@
+package jwt
+
+import (
+	"github.com/weloe/token-go/model"
+	"testing"
+)
+
+type testAuthManager struct{}
+
+func (m *testAuthManager) GetRole(id string) []string {
+	return []string{"admin", "user"}
+}
+
+func (m *testAuthManager) GetPermission(id string) []string {
+	return []string{"user:*", "order:*:read", "goods:add"}
+}
+
+func (m *testAuthManager) GetScope(id string) []string {
+	return []string{"openid", "profile"}
+}
+
+func TestStatelessEnforcer_AuthClaims(t *testing.T) {
+	enforcer := newTestEnforcer(t)
+	enforcer.SetSecretKey("123")
+	enforcer.SetAuth(&testAuthManager{})
+
+	token, err := enforcer.LoginByModel("1", &model.Login{Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	if scopes, err := enforcer.GetScopesByToken(token); err != nil || len(scopes) != 2 || scopes[1] != "profile" {
+		t.Errorf("GetScopesByToken() failed: scopes = %v, err = %v", scopes, err)
+	}
+	if roles, err := enforcer.GetRolesByToken(token); err != nil || len(roles) != 2 {
+		t.Errorf("GetRolesByToken() failed: roles = %v, err = %v", roles, err)
+	}
+
+	// auth manager is not used when checking
+	enforcer.SetAuth(nil)
+	c, _ := newTestRequestContext(t, enforcer, token)
+
+	tests := []struct {
+		name  string
+		check func() error
+		want  bool
+	}{
+		{"role", func() error { return enforcer.CheckRole(c, "admin") }, true},
+		{"role missing", func() error { return enforcer.CheckRole(c, "root") }, false},
+		{"role and", func() error { return enforcer.CheckRoleAnd(c, "admin", "user") }, true},
+		{"role and missing", func() error { return enforcer.CheckRoleAnd(c, "admin", "root") }, false},
+		{"role or", func() error { return enforcer.CheckRoleOr(c, "root", "user") }, true},
+		{"role or missing", func() error { return enforcer.CheckRoleOr(c, "root", "guest") }, false},
+		{"permission wildcard", func() error { return enforcer.CheckPermission(c, "user:add") }, true},
+		{"permission wildcard rest", func() error { return enforcer.CheckPermission(c, "user:add:self") }, true},
+		{"permission wildcard segment", func() error { return enforcer.CheckPermission(c, "order:1:read") }, true},
+		{"permission wildcard segment missing", func() error { return enforcer.CheckPermission(c, "order:1:write") }, false},
+		{"permission prefix", func() error { return enforcer.CheckPermission(c, "user") }, false},
+		{"permission and", func() error { return enforcer.CheckPermissionAnd(c, "user:add", "goods:add") }, true},
+		{"permission and missing", func() error { return enforcer.CheckPermissionAnd(c, "user:add", "goods:delete") }, false},
+		{"permission or", func() error { return enforcer.CheckPermissionOr(c, "goods:delete", "goods:add") }, true},
+		{"scope and", func() error { return enforcer.CheckScopeAnd(c, "openid", "profile") }, true},
+		{"scope and missing", func() error { return enforcer.CheckScopeAnd(c, "openid", "email") }, false},
+		{"scope or", func() error { return enforcer.CheckScopeOr(c, "email", "openid") }, true},
+		{"empty", func() error { return enforcer.CheckRoleOr(c) }, false},
+	}
+	for _, tt := range tests {
+		err = tt.check()
+		if (err == nil) != tt.want {
+			t.Errorf("%v: err = %v, want ok = %v", tt.name, err, tt.want)
+		}
+	}
+
+	other, err := enforcer.LoginByModel("2", &model.Login{Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	c, _ = newTestRequestContext(t, enforcer, other)
+	if err = enforcer.CheckRole(c, "admin"); err == nil {
+		t.Errorf("CheckRole() should fail without roles claim")
+	}
+}
+
+func TestMatchPermission(t *testing.T) {
+	tests := []struct {
+		granted  string
+		required string
+		want     bool
+	}{
+		{"*", "user:add", true},
+		{"user:add", "user:add", true},
+		{"user:*", "user:add", true},
+		{"user:*", "goods:add", false},
+		{"*:read", "user:read", true},
+		{"*:read", "user:write", false},
+		{"user:add", "user:add:self", false},
+	}
+	for _, tt := range tests {
+		if got := matchPermission(tt.granted, tt.required); got != tt.want {
+			t.Errorf("matchPermission(%v, %v) = %v, want %v", tt.granted, tt.required, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package jwt
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/weloe/token-go/util"
+	"time"
+)
+
+/* RFC 7519 registered claim key */
+const (
+	ISSUER     = "iss"
+	SUBJECT    = "sub"
+	AUDIENCE   = "aud"
+	EXPIRES_AT = "exp"
+	NOT_BEFORE = "nbf"
+	ISSUED_AT  = "iat"
+)
+
+// ClaimsMode the layout of JWT payload
+type ClaimsMode int
+
+const (
+	// LegacyClaims loginId, loginType, device, eff in milliseconds
+	LegacyClaims ClaimsMode = iota
+	// RegisteredClaims RFC 7519 registered claims, sub for loginId, exp | nbf | iat in seconds, with loginType and device
+	RegisteredClaims
+)
+
+// ClaimsOptions configure the claims layout of created token and the registered claims verification
+type ClaimsOptions struct {
+	// Mode claims layout of created token, default is LegacyClaims.
+	// Both layouts are readable whatever the mode is
+	Mode ClaimsMode
+	// Issuer set to iss claim, if not empty, token must have the same iss
+	Issuer string
+	// Audience set to aud claim, if not empty, token must contain one of them
+	Audience []string
+	// AllowLegacy accept legacy layout token without iss and aud, such as tokens created before Issuer is set
+	AllowLegacy bool
+}
+
+// newRegisteredClaims create JWT payload with registered claims
+func newRegisteredClaims(loginType string, loginId string, device string, timeout int64, extraData map[string]interface{}, options ClaimsOptions, now time.Time) (jwt.MapClaims, error) {
+	randomString32, err := util.GenerateRandomString32()
+	if err != nil {
+		return nil, err
+	}
+	claims := jwt.MapClaims{
+		LOGIN_TYPE: loginType,
+		SUBJECT:    loginId,
+		DEVICE:     device,
+		ISSUED_AT:  now.Unix(),
+		NOT_BEFORE: now.Unix(),
+		JTI:        randomString32,
+	}
+	// never expire token does not have exp
+	if timeout > NEVER_EXPIRE {
+		claims[EXPIRES_AT] = now.Unix() + timeout
+	}
+	addIssuerAudience(claims, options)
+	if extraData != nil {
+		claims[EXTRA_DATA] = extraData
+	}
+	return claims, nil
+}
+
+// addIssuerAudience set iss and aud claims of ClaimsOptions
+func addIssuerAudience(claims jwt.MapClaims, options ClaimsOptions) {
+	if options.Issuer != "" {
+		claims[ISSUER] = options.Issuer
+	}
+	if len(options.Audience) == 1 {
+		claims[AUDIENCE] = options.Audience[0]
+	} else if len(options.Audience) > 1 {
+		claims[AUDIENCE] = options.Audience
+	}
+}
+
+// isRegisteredLayout legacy token always has eff claim
+func isRegisteredLayout(payloads jwt.MapClaims) bool {
+	_, ok := payloads[EFF]
+	return !ok
+}
+
+// claimLoginId get loginId from loginId claim or sub claim
+func claimLoginId(payloads jwt.MapClaims) (string, bool) {
+	if isRegisteredLayout(payloads) {
+		id, ok := payloads[SUBJECT].(string)
+		return id, ok
+	}
+	id, ok := payloads[LOGIN_ID].(string)
+	return id, ok
+}
+
+// claimExpiration get expiration time in milliseconds from eff claim or exp claim,
+// return NEVER_EXPIRE if registered layout token does not have exp
+func claimExpiration(payloads jwt.MapClaims) (int64, bool) {
+	if isRegisteredLayout(payloads) {
+		exp, ok := payloads[EXPIRES_AT]
+		if !ok {
+			return NEVER_EXPIRE, true
+		}
+		expFloat, ok := exp.(float64)
+		return int64(expFloat) * 1000, ok
+	}
+	eff, ok := payloads[EFF].(float64)
+	return int64(eff), ok
+}
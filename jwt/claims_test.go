@@ -0,0 +1,120 @@
+package jwt
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/weloe/token-go/model"
+	"testing"
+	"time"
+)
+
+func newTestRegisteredEnforcer(t *testing.T, options ClaimsOptions) *StatelessEnforcer {
+	enforcer := newTestEnforcer(t)
+	enforcer.SetSecretKey("123")
+	options.Mode = RegisteredClaims
+	enforcer.SetClaimsOptions(options)
+	return enforcer
+}
+
+func TestRegisteredClaims(t *testing.T) {
+	enforcer := newTestRegisteredEnforcer(t, ClaimsOptions{Issuer: "token-go", Audience: []string{"api"}})
+
+	token, err := enforcer.LoginByModel("1", &model.Login{Device: "pc", Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+
+	// standard library can read registered claims
+	standard := &jwt.RegisteredClaims{}
+	_, err = jwt.ParseWithClaims(token, standard, func(token *jwt.Token) (interface{}, error) {
+		return []byte("123"), nil
+	})
+	if err != nil {
+		t.Fatalf("ParseWithClaims() failed: %v", err)
+	}
+	now := time.Now().Unix()
+	if standard.Subject != "1" || standard.Issuer != "token-go" || len(standard.Audience) != 1 || standard.Audience[0] != "api" || standard.ID == "" {
+		t.Errorf("unexpected standard claims: %+v", standard)
+	}
+	if standard.ExpiresAt == nil || standard.IssuedAt == nil || standard.NotBefore == nil {
+		t.Fatalf("missing time claims: %+v", standard)
+	}
+	if standard.ExpiresAt.Unix() < now+59 || standard.ExpiresAt.Unix() > now+60 || standard.IssuedAt.Unix() > now || standard.NotBefore.Unix() > now {
+		t.Errorf("unexpected time claims: %+v", standard)
+	}
+
+	if id, err := enforcer.GetIdByToken(token); err != nil || id != "1" {
+		t.Errorf("GetIdByToken() failed: id = %v, err = %v", id, err)
+	}
+	if timeout, err := enforcer.GetTokenTimeout(token); err != nil || timeout < 59 || timeout > 60 {
+		t.Errorf("GetTokenTimeout() failed: timeout = %v, err = %v", timeout, err)
+	}
+
+	other := newTestRegisteredEnforcer(t, ClaimsOptions{Issuer: "other"})
+	if _, err = other.GetIdByToken(token); err == nil {
+		t.Errorf("GetIdByToken() should reject token with wrong issuer")
+	}
+	other = newTestRegisteredEnforcer(t, ClaimsOptions{Issuer: "token-go", Audience: []string{"admin"}})
+	if _, err = other.GetIdByToken(token); err == nil {
+		t.Errorf("GetIdByToken() should reject token with wrong audience")
+	}
+	other = newTestRegisteredEnforcer(t, ClaimsOptions{Audience: []string{"admin", "api"}})
+	if _, err = other.GetIdByToken(token); err != nil {
+		t.Errorf("GetIdByToken() failed: %v", err)
+	}
+}
+
+func TestRegisteredClaims_NotBefore(t *testing.T) {
+	enforcer := newTestRegisteredEnforcer(t, ClaimsOptions{})
+	claims, err := newRegisteredClaims("user", "1", "pc", 60, nil, ClaimsOptions{}, time.Now())
+	if err != nil {
+		t.Fatalf("newRegisteredClaims() failed: %v", err)
+	}
+	claims[NOT_BEFORE] = time.Now().Unix() + 60
+	token, err := generateToken(claims, enforcer.keys())
+	if err != nil {
+		t.Fatalf("generateToken() failed: %v", err)
+	}
+	if _, err = enforcer.GetIdByToken(token); err == nil {
+		t.Errorf("GetIdByToken() should reject token before nbf")
+	}
+}
+
+func TestRegisteredClaims_ReadLegacy(t *testing.T) {
+	token, err := createToken("user", "1", "pc", 60, nil, "123")
+	if err != nil {
+		t.Fatalf("createToken() failed: %v", err)
+	}
+	enforcer := newTestRegisteredEnforcer(t, ClaimsOptions{Issuer: "token-go", Audience: []string{"api"}})
+	if _, err = enforcer.GetIdByToken(token); !errors.Is(err, ErrInvalidClaims) {
+		t.Errorf("GetIdByToken() legacy token without iss error = %v, want ErrInvalidClaims", err)
+	}
+
+	enforcer = newTestRegisteredEnforcer(t, ClaimsOptions{Issuer: "token-go", Audience: []string{"api"}, AllowLegacy: true})
+	if id, err := enforcer.GetIdByToken(token); err != nil || id != "1" {
+		t.Errorf("GetIdByToken() legacy token failed: id = %v, err = %v", id, err)
+	}
+	if timeout, err := enforcer.GetTokenTimeout(token); err != nil || timeout <= 0 {
+		t.Errorf("GetTokenTimeout() legacy token failed: timeout = %v, err = %v", timeout, err)
+	}
+}
+
+func TestLegacyClaims_IssuerAudience(t *testing.T) {
+	enforcer := newTestEnforcer(t)
+	enforcer.SetSecretKey("123")
+	enforcer.SetClaimsOptions(ClaimsOptions{Mode: LegacyClaims, Issuer: "token-go", Audience: []string{"api"}})
+	token, err := enforcer.LoginByModel("1", &model.Login{Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	if id, err := enforcer.GetIdByToken(token); err != nil || id != "1" {
+		t.Errorf("GetIdByToken() failed: id = %v, err = %v", id, err)
+	}
+
+	other := newTestEnforcer(t)
+	other.SetSecretKey("123")
+	other.SetClaimsOptions(ClaimsOptions{Mode: LegacyClaims, Audience: []string{"admin"}})
+	if _, err = other.GetIdByToken(token); !errors.Is(err, ErrInvalidClaims) {
+		t.Errorf("GetIdByToken() error = %v, want ErrInvalidClaims for other audience", err)
+	}
+}
@@ -0,0 +1,23 @@
+package jwt
+
+import (
+	"time"
+)
+
+// Clock return the current time, used to create and verify token
+type Clock func() time.Time
+
+// SetClock set the clock used to create and verify token, default is time.Now
+func (s *StatelessEnforcer) SetClock(clock Clock) {
+	s.validator.clock = clock
+}
+
+// SetLeeway set the tolerance of expiration and not-before checks for clock skew between nodes
+func (s *StatelessEnforcer) SetLeeway(leeway time.Duration) {
+	s.validator.leeway = leeway
+}
+
+// GetLeeway get the tolerance of expiration and not-before checks
+func (s *StatelessEnforcer) GetLeeway() time.Duration {
+	return s.validator.leeway
+}
@@ -0,0 +1,80 @@
+package jwt
+
+import (
+	"github.com/weloe/token-go/model"
+	"testing"
+	"time"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) Add(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestClockEnforcer(t *testing.T, mode ClaimsMode) (*StatelessEnforcer, *testClock) {
+	clock := &testClock{now: time.Unix(1700000000, 0)}
+	enforcer := newTestEnforcer(t)
+	enforcer.SetSecretKey("123")
+	enforcer.SetClaimsOptions(ClaimsOptions{Mode: mode})
+	enforcer.SetClock(clock.Now)
+	return enforcer, clock
+}
+
+func TestStatelessEnforcer_Clock(t *testing.T) {
+	for _, mode := range []ClaimsMode{LegacyClaims, RegisteredClaims} {
+		enforcer, clock := newTestClockEnforcer(t, mode)
+
+		token, err := enforcer.LoginByModel("1", &model.Login{Timeout: 60}, nil)
+		if err != nil {
+			t.Fatalf("LoginByModel() failed: %v", err)
+		}
+		clock.Add(30 * time.Second)
+		if timeout, err := enforcer.GetTokenTimeout(token); err != nil || timeout != 30 {
+			t.Errorf("mode %v GetTokenTimeout() failed: timeout = %v, err = %v", mode, timeout, err)
+		}
+
+		clock.Add(33 * time.Second)
+		if _, err = enforcer.GetIdByToken(token); err == nil {
+			t.Errorf("mode %v GetIdByToken() should reject expired token", mode)
+		}
+		if timeout, err := enforcer.GetTokenTimeout(token); err != nil || timeout != NOT_VALUE_EXPIRE {
+			t.Errorf("mode %v GetTokenTimeout() failed: timeout = %v, err = %v", mode, timeout, err)
+		}
+
+		// expired 3s ago, accepted with 5s leeway
+		enforcer.SetLeeway(5 * time.Second)
+		if _, err = enforcer.GetIdByToken(token); err != nil {
+			t.Errorf("mode %v GetIdByToken() with leeway failed: %v", mode, err)
+		}
+		clock.Add(3 * time.Second)
+		if _, err = enforcer.GetIdByToken(token); err == nil {
+			t.Errorf("mode %v GetIdByToken() should reject token expired beyond leeway", mode)
+		}
+	}
+}
+
+func TestStatelessEnforcer_LeewayNotBefore(t *testing.T) {
+	issuer, clock := newTestClockEnforcer(t, RegisteredClaims)
+	verifier, _ := newTestClockEnforcer(t, RegisteredClaims)
+
+	// issuer clock is 3s ahead of verifier
+	clock.Add(3 * time.Second)
+	token, err := issuer.LoginByModel("1", &model.Login{Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	if _, err = verifier.GetIdByToken(token); err == nil {
+		t.Errorf("GetIdByToken() should reject token before nbf")
+	}
+	verifier.SetLeeway(5 * time.Second)
+	if _, err = verifier.GetIdByToken(token); err != nil {
+		t.Errorf("GetIdByToken() with leeway failed: %v", err)
+	}
+}
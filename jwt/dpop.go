@@ -0,0 +1,264 @@
+package jwt
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/weloe/token-go/ctx"
+	"github.com/weloe/token-go/model"
+	"math"
+	"strings"
+	"time"
+)
+
+/*
+	DPoP proof-of-possession, see RFC 9449
+*/
+
+/* DPoP claim key */
+const (
+	// CONFIRMATION cnf claim, the key which token is bound to, see RFC 7800
+	CONFIRMATION = "cnf"
+	// JKT JWK SHA-256 thumbprint member of cnf claim
+	JKT = "jkt"
+	// DPOP_HEADER default request header of DPoP proof
+	DPOP_HEADER = "DPoP"
+	// DPOP_TYPE typ header of DPoP proof
+	DPOP_TYPE = "dpop+jwt"
+)
+
+// PROOF_STORAGE_KEY request storage key of the verified proof
+const PROOF_STORAGE_KEY ctx.StorageKey = "DPoP-Verified"
+
+// dpopMethods signing algorithms accepted for DPoP proof, the same as keys converted by JWK.SigningKey
+var dpopMethods = []string{"RS256", "ES256", "ES384", "ES512", "EdDSA"}
+
+// DPoPOptions DPoP proof verification options
+type DPoPOptions struct {
+	// Required reject token which is not bound to a key, default only bound token needs proof
+	Required bool
+	// ProofHeader request header of proof, default is DPoP
+	ProofHeader string
+	// MaxAge seconds, iat of proof must be within MaxAge, default is 60
+	MaxAge int64
+	// URL return the htu of request, default is ctx.Request().UrlNoQuery(). Set it behind reverse proxy
+	URL func(ctx ctx.Context) string
+}
+
+func initDPoPOptions(options *DPoPOptions) {
+	if options.ProofHeader == "" {
+		options.ProofHeader = DPOP_HEADER
+	}
+	if options.MaxAge <= 0 {
+		options.MaxAge = 60
+	}
+	if options.URL == nil {
+		options.URL = func(ctx ctx.Context) string {
+			return ctx.Request().UrlNoQuery()
+		}
+	}
+}
+
+// SetDPoPOptions set DPoP proof verification options
+func (s *StatelessEnforcer) SetDPoPOptions(options DPoPOptions) {
+	initDPoPOptions(&options)
+	s.dpopOptions = options
+}
+
+// GetDPoPOptions get DPoP proof verification options
+func (s *StatelessEnforcer) GetDPoPOptions() DPoPOptions {
+	options := s.dpopOptions
+	initDPoPOptions(&options)
+	return options
+}
+
+// LoginWithProof login by id and loginModel, the token is bound to the key of DPoP proof of login request.
+// The request of bound token must carry a proof signed by the same key, it is verified by
+// GetLoginId, GetClaims, GetExtraData and the auth checks. Token is not bound if verified by token only, such as GetIdByToken
+func (s *StatelessEnforcer) LoginWithProof(id string, loginModel *model.Login, ctx ctx.Context) (string, error) {
+	if loginModel == nil {
+		return "", errors.New("arg loginModel can not be nil")
+	}
+	jkt, err := s.verifyProof(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	claims, err := s.newClaims(id, loginModel.Device, loginModel.Timeout, loginModel.JwtData)
+	if err != nil {
+		return "", err
+	}
+	claims[CONFIRMATION] = map[string]interface{}{JKT: jkt}
+	token, err := s.generateToken(claims)
+	if err != nil {
+		return "", err
+	}
+	return s.responseLogin(id, token, loginModel, ctx)
+}
+
+// GetJktByToken parse token and get the thumbprint of the bound key, return empty string if token is not bound
+func (s *StatelessEnforcer) GetJktByToken(token string) (string, error) {
+	payloads, err := s.parseToken(token, true)
+	if err != nil {
+		return "", err
+	}
+	return getJkt(payloads), nil
+}
+
+// checkProof verify the DPoP proof of request if token is bound to a key
+func (s *StatelessEnforcer) checkProof(ctx ctx.Context, token string, payloads jwt.MapClaims) error {
+	jkt := getJkt(payloads)
+	if jkt == "" {
+		if s.dpopOptions.Required {
+			return newTokenError(ErrInvalidProof, token, errors.New("token is not bound to a key"))
+		}
+		return nil
+	}
+	proofJkt, err := s.verifyProof(ctx, token)
+	if err != nil {
+		return err
+	}
+	if proofJkt != jkt {
+		return newTokenError(ErrInvalidProof, token, errors.New("proof key does not match cnf"))
+	}
+	return nil
+}
+
+// verifyProof verify the DPoP proof of request, return the thumbprint of proof key.
+// If token is not empty, ath claim must be the hash of token. jti of proof is stored in adapter atomically to prevent replay,
+// the proof verified in a request is remembered by ctx.ReqStorage, so it can be verified again in the same request.
+// If ctx has no request storage, the proof can be verified only once
+func (s *StatelessEnforcer) verifyProof(ctx ctx.Context, token string) (string, error) {
+	if ctx == nil {
+		return "", newTokenError(ErrInvalidProof, token, errors.New("ctx.Context can not be nil"))
+	}
+	if !s.isAdapterEnabled() {
+		return "", errors.New("DPoP proof needs a persist.Adapter to prevent replay, please call SetAdapter()")
+	}
+	options := s.GetDPoPOptions()
+	proof := ctx.Request().Header(options.ProofHeader)
+	if proof == "" {
+		return "", newTokenError(ErrInvalidProof, token, errors.New("proof is missing"))
+	}
+
+	var jwk *JWK
+	parsed, err := jwt.NewParser(jwt.WithoutClaimsValidation(), jwt.WithValidMethods(dpopMethods)).Parse(proof, func(t *jwt.Token) (interface{}, error) {
+		if typ, _ := t.Header["typ"].(string); typ != DPOP_TYPE {
+			return nil, fmt.Errorf("invalid typ: %v", t.Header["typ"])
+		}
+		var err error
+		jwk, err = headerJWK(t.Header["jwk"])
+		if err != nil {
+			return nil, err
+		}
+		key, err := jwk.SigningKey()
+		if err != nil {
+			return nil, err
+		}
+		if t.Method.Alg() != key.Method().Alg() {
+			return nil, errors.New("Invalid signing algorithm: " + t.Method.Alg())
+		}
+		return key.VerifyKey(), nil
+	})
+	if err != nil {
+		return "", newTokenError(ErrInvalidProof, token, err)
+	}
+	claims, _ := parsed.Claims.(jwt.MapClaims)
+
+	jti, _ := claims[JTI].(string)
+	if jti == "" {
+		return "", newTokenError(ErrInvalidProof, token, errors.New("invalid jti"))
+	}
+	if htm, _ := claims["htm"].(string); htm != ctx.Request().Method() {
+		return "", newTokenError(ErrInvalidProof, token, fmt.Errorf("htm %v does not match request", htm))
+	}
+	if htu, _ := claims["htu"].(string); trimURL(htu) != trimURL(options.URL(ctx)) {
+		return "", newTokenError(ErrInvalidProof, token, fmt.Errorf("htu %v does not match request", htu))
+	}
+	iat, err := claims.GetIssuedAt()
+	if err != nil || iat == nil {
+		return "", newTokenError(ErrInvalidProof, token, errors.New("invalid iat"))
+	}
+	now := s.validator.now()
+	leeway := s.validator.leeway
+	if iat.Before(now.Add(-time.Duration(options.MaxAge)*time.Second-leeway)) || iat.After(now.Add(leeway)) {
+		return "", newTokenError(ErrInvalidProof, token, errors.New("proof is expired or issued in the future"))
+	}
+	if token != "" {
+		if ath, _ := claims["ath"].(string); ath != tokenHash(token) {
+			return "", newTokenError(ErrInvalidProof, token, errors.New("ath does not match token"))
+		}
+	}
+
+	// the same request may be verified by several accessors, such as GetLoginId and CheckPermission
+	fingerprint := tokenHash(proof + "." + token)
+	storage := ctx.ReqStorage()
+	if storage != nil {
+		if verified, _ := storage.Get(PROOF_STORAGE_KEY).(string); verified == fingerprint {
+			return jwk.Thumbprint()
+		}
+	}
+	// proof is accepted within MaxAge and leeway of both sides
+	key := s.spliceProofKey(jti)
+	ok, err := s.setStrIfAbsent(key, fingerprint, options.MaxAge+2*int64(math.Ceil(leeway.Seconds()))+1)
+	if err != nil {
+		return "", err
+	}
+	// ctx without storage can not remember the request, the proof is verified only once
+	if !ok {
+		return "", newTokenError(ErrInvalidProof, token, errors.New("proof is replayed"))
+	}
+	if storage != nil {
+		storage.Set(PROOF_STORAGE_KEY, fingerprint)
+	}
+	return jwk.Thumbprint()
+}
+
+func (s *StatelessEnforcer) spliceProofKey(jti string) string {
+	return s.GetTokenConfig().TokenName + ":" + s.GetType() + ":dpop:" + tokenHash(jti)
+}
+
+// headerJWK convert jwk header of proof to JWK, private key is rejected
+func headerJWK(value interface{}) (*JWK, error) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid jwk header")
+	}
+	if _, ok = m["d"]; ok {
+		return nil, errors.New("jwk header can not contain private key")
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	jwk := &JWK{}
+	err = json.Unmarshal(b, jwk)
+	if err != nil {
+		return nil, err
+	}
+	return jwk, nil
+}
+
+func getJkt(payloads jwt.MapClaims) string {
+	cnf, ok := payloads[CONFIRMATION].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	jkt, _ := cnf[JKT].(string)
+	return jkt
+}
+
+// tokenHash base64url encoded SHA-256 hash, used as ath claim of proof
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return encodeBase64(sum[:])
+}
+
+// trimURL remove query and fragment, they are not covered by htu
+func trimURL(url string) string {
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		return url[:i]
+	}
+	return url
+}
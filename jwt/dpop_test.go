@@ -0,0 +1,266 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	tokenGo "github.com/weloe/token-go"
+	"github.com/weloe/token-go/ctx"
+	"github.com/weloe/token-go/model"
+	"github.com/weloe/token-go/persist"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testProofKey struct {
+	privateKey *ecdsa.PrivateKey
+	jwk        map[string]interface{}
+	jkt        string
+}
+
+func newTestProofKey(t *testing.T) *testProofKey {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() failed: %v", err)
+	}
+	publicKey, _ := NewECDSAPublicKey(&privateKey.PublicKey)
+	jwk, err := NewJWK("", publicKey)
+	if err != nil {
+		t.Fatalf("NewJWK() failed: %v", err)
+	}
+	jkt, err := jwk.Thumbprint()
+	if err != nil {
+		t.Fatalf("Thumbprint() failed: %v", err)
+	}
+	b, _ := json.Marshal(jwk)
+	m := make(map[string]interface{})
+	_ = json.Unmarshal(b, &m)
+	return &testProofKey{privateKey: privateKey, jwk: m, jkt: jkt}
+}
+
+func (k *testProofKey) proof(t *testing.T, method string, url string, token string, iat time.Time) string {
+	claims := jwt.MapClaims{
+		JTI:   randomTestString(t),
+		"htm": method,
+		"htu": url,
+		"iat": iat.Unix(),
+	}
+	if token != "" {
+		claims["ath"] = tokenHash(token)
+	}
+	proof := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	proof.Header["typ"] = DPOP_TYPE
+	proof.Header["jwk"] = k.jwk
+	signed, err := proof.SignedString(k.privateKey)
+	if err != nil {
+		t.Fatalf("SignedString() failed: %v", err)
+	}
+	return signed
+}
+
+func randomTestString(t *testing.T) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return encodeBase64(b)
+}
+
+func newTestProofContext(enforcer IEnforcer, method string, url string, token string, proof string) ctx.Context {
+	req := httptest.NewRequest(method, url, nil)
+	if token != "" {
+		req.Header.Set(enforcer.GetTokenConfig().TokenName, token)
+	}
+	if proof != "" {
+		req.Header.Set(DPOP_HEADER, proof)
+	}
+	return tokenGo.NewHttpContext(req, httptest.NewRecorder())
+}
+
+func TestJWK_Thumbprint(t *testing.T) {
+	// example of RFC 7638 section 3.1
+	jwk := &JWK{
+		Kty: "RSA",
+		N:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E:   "AQAB",
+		Alg: "RS256",
+		Kid: "2011-04-29",
+	}
+	if jkt, err := jwk.Thumbprint(); err != nil || jkt != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("Thumbprint() = %v, err = %v", jkt, err)
+	}
+}
+
+func TestStatelessEnforcer_DPoP(t *testing.T) {
+	const loginURL = "https://example.com/login"
+	const apiURL = "https://example.com/api/orders"
+	key := newTestProofKey(t)
+
+	enforcer := newTestEnforcer(t)
+	enforcer.SetSecretKey("123")
+	c := newTestProofContext(enforcer, "POST", loginURL, "", key.proof(t, "POST", loginURL, "", time.Now()))
+	if _, err := enforcer.LoginWithProof("1", model.DefaultLoginModel(), c); err == nil {
+		t.Errorf("LoginWithProof() should fail without adapter")
+	}
+
+	enforcer = newTestAdapterEnforcer(t)
+	if _, err := enforcer.LoginWithProof("1", model.DefaultLoginModel(), newTestProofContext(enforcer, "POST", loginURL, "", "")); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("LoginWithProof() error = %v, want ErrInvalidProof", err)
+	}
+	c = newTestProofContext(enforcer, "POST", loginURL, "", key.proof(t, "POST", loginURL, "", time.Now()))
+	token, err := enforcer.LoginWithProof("1", model.DefaultLoginModel(), c)
+	if err != nil {
+		t.Fatalf("LoginWithProof() failed: %v", err)
+	}
+	if jkt, err := enforcer.GetJktByToken(token); err != nil || jkt != key.jkt {
+		t.Errorf("GetJktByToken() = %v, err = %v, want %v", jkt, err, key.jkt)
+	}
+
+	proof := key.proof(t, "GET", apiURL+"?page=1", token, time.Now())
+	c = newTestProofContext(enforcer, "GET", apiURL+"?page=1", token, proof)
+	if id, err := enforcer.GetLoginId(c); err != nil || id != "1" {
+		t.Errorf("GetLoginId() failed: id = %v, err = %v", id, err)
+	}
+	// verified again in the same request
+	if id, err := enforcer.GetLoginId(c); err != nil || id != "1" {
+		t.Errorf("GetLoginId() in the same request failed: id = %v, err = %v", id, err)
+	}
+	c = newTestProofContext(enforcer, "GET", apiURL+"?page=1", token, proof)
+	if _, err = enforcer.GetLoginId(c); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("GetLoginId() error = %v, want ErrInvalidProof for replayed proof", err)
+	}
+
+	other := newTestProofKey(t)
+	for name, proof := range map[string]string{
+		"missing":   "",
+		"other key": other.proof(t, "GET", apiURL, token, time.Now()),
+		"htm":       key.proof(t, "POST", apiURL, token, time.Now()),
+		"htu":       key.proof(t, "GET", "https://example.com/api/users", token, time.Now()),
+		"ath":       key.proof(t, "GET", apiURL, token+"a", time.Now()),
+		"iat":       key.proof(t, "GET", apiURL, token, time.Now().Add(-2*time.Minute)),
+	} {
+		c = newTestProofContext(enforcer, "GET", apiURL, token, proof)
+		if _, err = enforcer.GetExtraData(c, "name"); !errors.Is(err, ErrInvalidProof) {
+			t.Errorf("%v GetExtraData() error = %v, want ErrInvalidProof", name, err)
+		}
+	}
+
+	// token only api does not verify proof
+	if id, err := enforcer.GetIdByToken(token); err != nil || id != "1" {
+		t.Errorf("GetIdByToken() failed: id = %v, err = %v", id, err)
+	}
+
+	// unbound token
+	unbound, _ := enforcer.Login("1", nil)
+	c = newTestProofContext(enforcer, "GET", apiURL, unbound, "")
+	if _, err = enforcer.GetLoginId(c); err != nil {
+		t.Errorf("GetLoginId() failed: %v", err)
+	}
+	enforcer.SetDPoPOptions(DPoPOptions{Required: true})
+	if _, err = enforcer.GetLoginId(c); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("GetLoginId() error = %v, want ErrInvalidProof for unbound token", err)
+	}
+}
+
+func TestDPoP_RequestEntryPoints(t *testing.T) {
+	const loginURL = "https://example.com/login"
+	const apiURL = "https://example.com/api/orders"
+	key := newTestProofKey(t)
+
+	login := func(enforcer *StatelessEnforcer) string {
+		c := newTestProofContext(enforcer, "POST", loginURL, "", key.proof(t, "POST", loginURL, "", time.Now()))
+		token, err := enforcer.LoginWithProof("1", model.DefaultLoginModel(), c)
+		if err != nil {
+			t.Fatalf("LoginWithProof() failed: %v", err)
+		}
+		return token
+	}
+
+	enforcer := newTestAdapterEnforcer(t)
+	token := login(enforcer)
+	stolen := newTestProofContext(enforcer, "GET", apiURL, token, "")
+	if _, err := enforcer.GetTokenTimeoutByCtx(stolen); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("GetTokenTimeoutByCtx() error = %v, want ErrInvalidProof", err)
+	}
+	if err := enforcer.Logout(stolen); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("Logout() error = %v, want ErrInvalidProof", err)
+	}
+	// every accessor of the same request accepts the proof
+	c := newTestProofContext(enforcer, "GET", apiURL, token, key.proof(t, "GET", apiURL, token, time.Now()))
+	if _, err := enforcer.GetLoginId(c); err != nil {
+		t.Errorf("GetLoginId() failed: %v", err)
+	}
+	if _, err := enforcer.GetClaims(c); err != nil {
+		t.Errorf("GetClaims() failed: %v", err)
+	}
+	if _, err := enforcer.GetExtraData(c, "name"); err != nil {
+		t.Errorf("GetExtraData() failed: %v", err)
+	}
+	if timeout, err := enforcer.GetTokenTimeoutByCtx(c); err != nil || timeout <= 0 {
+		t.Errorf("GetTokenTimeoutByCtx() = %v, err = %v", timeout, err)
+	}
+
+	mixed := newTestMixedEnforcer(t)
+	token = login(mixed.StatelessEnforcer)
+	if ok, err := mixed.IsLogin(newTestProofContext(mixed, "GET", apiURL, token, "")); ok || !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("MixedEnforcer.IsLogin() = %v, err = %v, want ErrInvalidProof", ok, err)
+	}
+	if err := mixed.Logout(newTestProofContext(mixed, "GET", apiURL, token, "")); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("MixedEnforcer.Logout() error = %v, want ErrInvalidProof", err)
+	}
+	c = newTestProofContext(mixed, "GET", apiURL, token, key.proof(t, "GET", apiURL, token, time.Now()))
+	if ok, err := mixed.IsLogin(c); !ok || err != nil {
+		t.Errorf("MixedEnforcer.IsLogin() = %v, err = %v", ok, err)
+	}
+
+	manager, err := NewEnforcerManager(LoginTypeProfile{LoginType: "user", Secret: "123", Adapter: persist.NewDefaultAdapter()})
+	if err != nil {
+		t.Fatalf("NewEnforcerManager() failed: %v", err)
+	}
+	enforcer, _ = manager.GetEnforcer("user")
+	token = login(enforcer)
+	if _, err = manager.GetLoginId(newTestProofContext(enforcer, "GET", apiURL, token, "")); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("EnforcerManager.GetLoginId() error = %v, want ErrInvalidProof", err)
+	}
+	c = newTestProofContext(enforcer, "GET", apiURL, token, key.proof(t, "GET", apiURL, token, time.Now()))
+	if id, err := manager.GetLoginId(c); err != nil || id != "1" {
+		t.Errorf("EnforcerManager.GetLoginId() = %v, err = %v", id, err)
+	}
+}
+
+// testNoStorageContext ctx which does not provide request storage
+type testNoStorageContext struct {
+	ctx.Context
+}
+
+func (c *testNoStorageContext) ReqStorage() ctx.ReqStorage {
+	return nil
+}
+
+func TestDPoP_ContextWithoutStorage(t *testing.T) {
+	const loginURL = "https://example.com/login"
+	const apiURL = "https://example.com/api/orders"
+	key := newTestProofKey(t)
+
+	enforcer := newTestAdapterEnforcer(t)
+	c := newTestProofContext(enforcer, "POST", loginURL, "", key.proof(t, "POST", loginURL, "", time.Now()))
+	token, err := enforcer.LoginWithProof("1", model.DefaultLoginModel(), c)
+	if err != nil {
+		t.Fatalf("LoginWithProof() failed: %v", err)
+	}
+
+	proof := key.proof(t, "GET", apiURL, token, time.Now())
+	c = &testNoStorageContext{newTestProofContext(enforcer, "GET", apiURL, token, proof)}
+	if _, err = enforcer.GetLoginId(c); err != nil {
+		t.Fatalf("GetLoginId() failed: %v", err)
+	}
+	// the replayed proof with the same token is rejected
+	for i := 0; i < 2; i++ {
+		c = &testNoStorageContext{newTestProofContext(enforcer, "GET", apiURL, token, proof)}
+		if _, err = enforcer.GetLoginId(c); !errors.Is(err, ErrInvalidProof) {
+			t.Errorf("GetLoginId() error = %v, want ErrInvalidProof for replayed proof", err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"github.com/go-jose/go-jose/v3"
+)
+
+var _ EncryptionKey = (*encryptionKey)(nil)
+
+// EncryptionKey used to encrypt and decrypt JWE token
+type EncryptionKey interface {
+	// Algorithm return the key management algorithm, such as dir, ECDH-ES
+	Algorithm() jose.KeyAlgorithm
+	// Encryption return the content encryption algorithm, such as A256GCM
+	Encryption() jose.ContentEncryption
+	// EncryptKey return the key used to encrypt token
+	EncryptKey() interface{}
+	// DecryptKey return the key used to decrypt token
+	DecryptKey() interface{}
+}
+
+type encryptionKey struct {
+	algorithm  jose.KeyAlgorithm
+	encryption jose.ContentEncryption
+	encryptKey interface{}
+	decryptKey interface{}
+}
+
+func (k *encryptionKey) Algorithm() jose.KeyAlgorithm {
+	return k.algorithm
+}
+
+func (k *encryptionKey) Encryption() jose.ContentEncryption {
+	return k.encryption
+}
+
+func (k *encryptionKey) EncryptKey() interface{} {
+	return k.encryptKey
+}
+
+func (k *encryptionKey) DecryptKey() interface{} {
+	return k.decryptKey
+}
+
+// NewDirectKey dir + A256GCM key, use the 32 bytes key to encrypt and decrypt
+func NewDirectKey(key []byte) (EncryptionKey, error) {
+	if len(key) != 32 {
+		return nil, errors.New("A256GCM key must be 32 bytes")
+	}
+	key = append([]byte(nil), key...)
+	return &encryptionKey{
+		algorithm:  jose.DIRECT,
+		encryption: jose.A256GCM,
+		encryptKey: key,
+		decryptKey: key,
+	}, nil
+}
+
+// NewECDHESKey ECDH-ES + A256GCM key, encrypt with the public key of privateKey and decrypt with privateKey
+func NewECDHESKey(privateKey *ecdsa.PrivateKey) (EncryptionKey, error) {
+	if privateKey == nil {
+		return nil, errors.New("ecdsa private key can not be nil")
+	}
+	return &encryptionKey{
+		algorithm:  jose.ECDH_ES,
+		encryption: jose.A256GCM,
+		encryptKey: &privateKey.PublicKey,
+		decryptKey: privateKey,
+	}, nil
+}
@@ -1,7 +1,7 @@
 package jwt
 
 import (
-	"github.com/golang-jwt/jwt"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/weloe/token-go/config"
 	"github.com/weloe/token-go/ctx"
 	"github.com/weloe/token-go/log"
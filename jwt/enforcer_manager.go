@@ -0,0 +1,261 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/weloe/token-go/config"
+	"github.com/weloe/token-go/ctx"
+	"github.com/weloe/token-go/model"
+	"github.com/weloe/token-go/persist"
+	"sync"
+)
+
+// LoginTypeProfile options of a login type managed by EnforcerManager
+type LoginTypeProfile struct {
+	// LoginType such as user, admin
+	LoginType string
+	// Secret HS256 secret, one of Secret, Key, KeyRing and KeyProvider must be set
+	Secret      string
+	Key         SigningKey
+	KeyRing     *KeyRing
+	KeyProvider KeyProvider
+	// Timeout default token timeout seconds, used when Timeout of login model is 0, default is 30 days
+	Timeout       int64
+	ClaimsOptions ClaimsOptions
+	// Config token config such as TokenName, default is config.DefaultTokenConfig()
+	Config *config.TokenConfig
+	// Adapter used by revocation and kickout, default is persist.EmptyAdapter
+	Adapter persist.Adapter
+}
+
+// EnforcerManager hold a StatelessEnforcer for each login type, route login by login type
+// and detect the login type of presented token
+type EnforcerManager struct {
+	mu         sync.RWMutex
+	enforcers  map[string]*StatelessEnforcer
+	profiles   map[string]LoginTypeProfile
+	loginTypes []string
+}
+
+// NewEnforcerManager new manager with login type profiles
+func NewEnforcerManager(profiles ...LoginTypeProfile) (*EnforcerManager, error) {
+	m := &EnforcerManager{
+		enforcers: make(map[string]*StatelessEnforcer),
+		profiles:  make(map[string]LoginTypeProfile),
+	}
+	for _, profile := range profiles {
+		_, err := m.AddProfile(profile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// AddProfile create the enforcer of login type, return error if login type exists
+func (m *EnforcerManager) AddProfile(profile LoginTypeProfile) (*StatelessEnforcer, error) {
+	if profile.LoginType == "" {
+		return nil, errors.New("arg LoginType can not be nil")
+	}
+	if profile.Secret == "" && profile.Key == nil && profile.KeyRing == nil && profile.KeyProvider == nil {
+		return nil, fmt.Errorf("login type %v needs one of Secret, Key, KeyRing and KeyProvider", profile.LoginType)
+	}
+
+	var enforcer *StatelessEnforcer
+	var err error
+	if profile.Config != nil {
+		enforcer, err = NewEnforcer(profile.Config)
+	} else {
+		enforcer, err = NewEnforcer()
+	}
+	if err != nil {
+		return nil, err
+	}
+	enforcer.SetType(profile.LoginType)
+	enforcer.SetSecretKey(profile.Secret)
+	enforcer.SetSigningKey(profile.Key)
+	enforcer.SetKeyProvider(profile.KeyProvider)
+	if profile.KeyRing != nil {
+		enforcer.SetKeyRing(profile.KeyRing)
+	}
+	enforcer.SetClaimsOptions(profile.ClaimsOptions)
+	if profile.Adapter != nil {
+		enforcer.SetAdapter(profile.Adapter)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.enforcers[profile.LoginType]; ok {
+		return nil, fmt.Errorf("login type %v already exists", profile.LoginType)
+	}
+	m.enforcers[profile.LoginType] = enforcer
+	m.profiles[profile.LoginType] = profile
+	m.loginTypes = append(m.loginTypes, profile.LoginType)
+	return enforcer, nil
+}
+
+// GetEnforcer get the enforcer of login type
+func (m *EnforcerManager) GetEnforcer(loginType string) (*StatelessEnforcer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	enforcer, ok := m.enforcers[loginType]
+	if !ok {
+		return nil, fmt.Errorf("login type %v does not exist", loginType)
+	}
+	return enforcer, nil
+}
+
+// GetLoginTypes get login types in the order they are added
+func (m *EnforcerManager) GetLoginTypes() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]string(nil), m.loginTypes...)
+}
+
+// Login login by the enforcer of login type with default login model and the timeout of profile
+// ctx.Context can be nil
+func (m *EnforcerManager) Login(loginType string, id string, ctx ctx.Context) (string, error) {
+	loginModel := model.DefaultLoginModel()
+	loginModel.Timeout = 0
+	return m.LoginByModel(loginType, id, loginModel, ctx)
+}
+
+// LoginByModel login by the enforcer of login type, if Timeout of loginModel is 0, use the timeout of profile
+// ctx.Context can be nil
+func (m *EnforcerManager) LoginByModel(loginType string, id string, loginModel *model.Login, ctx ctx.Context) (string, error) {
+	if loginModel == nil {
+		return "", errors.New("arg loginModel can not be nil")
+	}
+	enforcer, err := m.GetEnforcer(loginType)
+	if err != nil {
+		return "", err
+	}
+	if loginModel.Timeout == 0 {
+		m.mu.RLock()
+		timeout := m.profiles[loginType].Timeout
+		m.mu.RUnlock()
+		if timeout == 0 {
+			timeout = model.DefaultLoginModel().Timeout
+		}
+		copied := *loginModel
+		copied.Timeout = timeout
+		loginModel = &copied
+	}
+	return enforcer.LoginByModel(id, loginModel, ctx)
+}
+
+// GetEnforcerByToken detect the enforcer of token. The loginType claim is read without verification to route token,
+// token which can not be read, such as JWE, is matched by decrypting with each profile.
+// Token is verified by the returned enforcer
+func (m *EnforcerManager) GetEnforcerByToken(token string) (*StatelessEnforcer, error) {
+	if loginType := peekLoginType(token); loginType != "" {
+		enforcer, err := m.GetEnforcer(loginType)
+		if err != nil {
+			return nil, newTokenError(ErrLoginTypeMismatch, token, err)
+		}
+		return enforcer, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, loginType := range m.loginTypes {
+		enforcer := m.enforcers[loginType]
+		if enforcer.GetEncryptionKey() == nil {
+			continue
+		}
+		if _, err := enforcer.decryptToken(token); err == nil {
+			return enforcer, nil
+		}
+	}
+	return nil, newTokenError(ErrLoginTypeMismatch, token, errors.New("no login type matches token"))
+}
+
+// GetLoginTypeByToken parse token and get its login type
+func (m *EnforcerManager) GetLoginTypeByToken(token string) (string, error) {
+	enforcer, err := m.GetEnforcerByToken(token)
+	if err != nil {
+		return "", err
+	}
+	_, err = enforcer.parseToken(token, true)
+	if err != nil {
+		return "", err
+	}
+	return enforcer.GetType(), nil
+}
+
+// GetIdByToken parse token by the enforcer of its login type and get id
+func (m *EnforcerManager) GetIdByToken(token string) (string, error) {
+	enforcer, err := m.GetEnforcerByToken(token)
+	if err != nil {
+		return "", err
+	}
+	return enforcer.GetIdByToken(token)
+}
+
+// GetClaimsByToken parse token by the enforcer of its login type and get claims
+func (m *EnforcerManager) GetClaimsByToken(token string) (jwt.Claims, error) {
+	enforcer, err := m.GetEnforcerByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return enforcer.GetClaimsByToken(token)
+}
+
+// GetExtraDataByToken parse token by the enforcer of its login type and get extra data
+func (m *EnforcerManager) GetExtraDataByToken(token string, key string) (interface{}, error) {
+	enforcer, err := m.GetEnforcerByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return enforcer.GetExtraDataByToken(token, key)
+}
+
+// GetTokenTimeout parse token by the enforcer of its login type and get timeout
+func (m *EnforcerManager) GetTokenTimeout(token string) (int64, error) {
+	enforcer, err := m.GetEnforcerByToken(token)
+	if err != nil {
+		return 0, err
+	}
+	return enforcer.GetTokenTimeout(token)
+}
+
+// GetLoginId get the token of request by the token name of each profile, parse it and get id
+func (m *EnforcerManager) GetLoginId(ctx ctx.Context) (string, error) {
+	token := m.GetRequestToken(ctx)
+	if token == "" {
+		return "", errors.New("token is nil")
+	}
+	enforcer, err := m.GetEnforcerByToken(token)
+	if err != nil {
+		return "", err
+	}
+	payloads, err := enforcer.parseRequestToken(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	return getIdFromClaims(token, payloads)
+}
+
+// GetRequestToken get token from request, profiles are checked in the order they are added
+func (m *EnforcerManager) GetRequestToken(ctx ctx.Context) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, loginType := range m.loginTypes {
+		if token := m.enforcers[loginType].GetRequestToken(ctx); token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// peekLoginType read loginType claim without verification, return empty string if token can not be read
+func peekLoginType(token string) string {
+	payloads := jwt.MapClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(token, payloads)
+	if err != nil {
+		return ""
+	}
+	loginType, _ := payloads[LOGIN_TYPE].(string)
+	return loginType
+}
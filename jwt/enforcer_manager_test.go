@@ -0,0 +1,129 @@
+package jwt
+
+import (
+	"errors"
+	"github.com/weloe/token-go/config"
+	"github.com/weloe/token-go/model"
+	"testing"
+)
+
+func newTestEnforcerManager(t *testing.T) *EnforcerManager {
+	adminConfig := config.DefaultTokenConfig()
+	adminConfig.TokenName = "admin-token"
+	m, err := NewEnforcerManager(
+		LoginTypeProfile{LoginType: "user", Secret: "user-secret", Timeout: 60},
+		LoginTypeProfile{
+			LoginType:     "admin",
+			Secret:        "admin-secret",
+			Timeout:       600,
+			ClaimsOptions: ClaimsOptions{Mode: RegisteredClaims, Issuer: "admin-api"},
+			Config:        adminConfig,
+		},
+	)
+	if err != nil {
+		t.Fatalf("NewEnforcerManager() failed: %v", err)
+	}
+	return m
+}
+
+func TestNewEnforcerManager(t *testing.T) {
+	if _, err := NewEnforcerManager(LoginTypeProfile{LoginType: "user"}); err == nil {
+		t.Errorf("NewEnforcerManager() should reject profile without key")
+	}
+	if _, err := NewEnforcerManager(LoginTypeProfile{Secret: "123"}); err == nil {
+		t.Errorf("NewEnforcerManager() should reject profile without login type")
+	}
+	m := newTestEnforcerManager(t)
+	if _, err := m.AddProfile(LoginTypeProfile{LoginType: "user", Secret: "123"}); err == nil {
+		t.Errorf("AddProfile() should reject duplicate login type")
+	}
+	if types := m.GetLoginTypes(); len(types) != 2 || types[0] != "user" || types[1] != "admin" {
+		t.Errorf("GetLoginTypes() = %v", types)
+	}
+}
+
+func TestEnforcerManager_Login(t *testing.T) {
+	m := newTestEnforcerManager(t)
+
+	userToken, err := m.Login("user", "1", nil)
+	if err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+	adminToken, err := m.LoginByModel("admin", "1", &model.Login{Device: "pc", JwtData: map[string]interface{}{"name": "weloe"}}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	if _, err = m.Login("guest", "1", nil); err == nil {
+		t.Errorf("Login() should reject unknown login type")
+	}
+
+	for token, want := range map[string]string{userToken: "user", adminToken: "admin"} {
+		if loginType, err := m.GetLoginTypeByToken(token); err != nil || loginType != want {
+			t.Errorf("GetLoginTypeByToken() = %v, err = %v, want %v", loginType, err, want)
+		}
+		if id, err := m.GetIdByToken(token); err != nil || id != "1" {
+			t.Errorf("GetIdByToken() failed: id = %v, err = %v", id, err)
+		}
+	}
+	if timeout, err := m.GetTokenTimeout(userToken); err != nil || timeout < 59 || timeout > 60 {
+		t.Errorf("GetTokenTimeout() failed: timeout = %v, err = %v", timeout, err)
+	}
+	if timeout, err := m.GetTokenTimeout(adminToken); err != nil || timeout < 599 || timeout > 600 {
+		t.Errorf("GetTokenTimeout() failed: timeout = %v, err = %v", timeout, err)
+	}
+	if data, err := m.GetExtraDataByToken(adminToken, "name"); err != nil || data != "weloe" {
+		t.Errorf("GetExtraDataByToken() failed: data = %v, err = %v", data, err)
+	}
+	claims, err := m.GetClaimsByToken(adminToken)
+	if err != nil {
+		t.Fatalf("GetClaimsByToken() failed: %v", err)
+	}
+	if issuer, _ := claims.GetIssuer(); issuer != "admin-api" {
+		t.Errorf("GetClaimsByToken() issuer = %v, want admin-api", issuer)
+	}
+
+	// request token is read by the token name of each profile
+	adminEnforcer, _ := m.GetEnforcer("admin")
+	c, _ := newTestRequestContext(t, adminEnforcer, adminToken)
+	if id, err := m.GetLoginId(c); err != nil || id != "1" {
+		t.Errorf("GetLoginId() failed: id = %v, err = %v", id, err)
+	}
+}
+
+func TestEnforcerManager_Reject(t *testing.T) {
+	m := newTestEnforcerManager(t)
+
+	// token claims admin but is signed with user secret
+	forged, err := createToken("admin", "1", "pc", 60, nil, "user-secret")
+	if err != nil {
+		t.Fatalf("createToken() failed: %v", err)
+	}
+	if _, err = m.GetIdByToken(forged); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("GetIdByToken() error = %v, want ErrInvalidSignature", err)
+	}
+
+	unknown, _ := createToken("guest", "1", "pc", 60, nil, "user-secret")
+	if _, err = m.GetIdByToken(unknown); !errors.Is(err, ErrLoginTypeMismatch) {
+		t.Errorf("GetIdByToken() error = %v, want ErrLoginTypeMismatch", err)
+	}
+	if _, err = m.GetIdByToken("a.b.c.d.e"); !errors.Is(err, ErrLoginTypeMismatch) {
+		t.Errorf("GetIdByToken() error = %v, want ErrLoginTypeMismatch", err)
+	}
+}
+
+func TestEnforcerManager_JWE(t *testing.T) {
+	m := newTestEnforcerManager(t)
+	keys := newTestEncryptionKeys(t)
+	for i, loginType := range m.GetLoginTypes() {
+		enforcer, _ := m.GetEnforcer(loginType)
+		enforcer.SetEncryptionKey(keys[[]string{"dir", "ECDH-ES"}[i]])
+	}
+
+	token, err := m.Login("admin", "1", nil)
+	if err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+	if loginType, err := m.GetLoginTypeByToken(token); err != nil || loginType != "admin" {
+		t.Errorf("GetLoginTypeByToken() = %v, err = %v, want admin", loginType, err)
+	}
+}
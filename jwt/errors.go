@@ -0,0 +1,97 @@
+package jwt
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrMalformed         = errors.New("JWT is malformed")
+	ErrInvalidSignature  = errors.New("JWT signature is invalid")
+	ErrExpired           = errors.New("JWT has expired")
+	ErrNotValidYet       = errors.New("JWT is not valid yet")
+	ErrLoginTypeMismatch = errors.New("JWT login type mismatch")
+	ErrTokenTypeMismatch = errors.New("JWT token type mismatch")
+	ErrInvalidClaims     = errors.New("JWT claims are invalid")
+	ErrRevoked           = errors.New("JWT has been revoked")
+	ErrInvalidProof      = errors.New("DPoP proof is invalid")
+)
+
+// TokenError token verification error, use errors.Is to check Kind and errors.As to get detail.
+// Token is redacted, it is safe to log
+type TokenError struct {
+	// Kind sentinel error, such as ErrExpired
+	Kind error
+	// Token redacted token, only the last characters of signature are kept
+	Token string
+	// Err the underlying error, such as golang-jwt errors
+	Err error
+}
+
+func (e *TokenError) Error() string {
+	msg := e.Kind.Error()
+	if e.Token != "" {
+		msg += " (token = " + e.Token + ")"
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Is report whether target is the Kind of error
+func (e *TokenError) Is(target error) bool {
+	return e.Kind == target
+}
+
+func (e *TokenError) Unwrap() error {
+	return e.Err
+}
+
+func newTokenError(kind error, token string, err error) *TokenError {
+	return &TokenError{
+		Kind:  kind,
+		Token: redactToken(token),
+		Err:   err,
+	}
+}
+
+// withToken replace the token of TokenError, used when the verified token is wrapped, such as JWE
+func withToken(err error, token string) error {
+	var tokenErr *TokenError
+	if errors.As(err, &tokenErr) {
+		tokenErr.Token = redactToken(token)
+	}
+	return err
+}
+
+// redactToken keep the last 6 characters of token, they are enough to match logs but can not be used
+func redactToken(token string) string {
+	if token == "" {
+		return ""
+	}
+	if len(token) <= 24 {
+		return "***"
+	}
+	return "***" + token[len(token)-6:]
+}
+
+// classifyError convert golang-jwt error to TokenError
+func classifyError(token string, err error) error {
+	var tokenErr *TokenError
+	if errors.As(err, &tokenErr) {
+		return err
+	}
+	kind := ErrInvalidClaims
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		kind = ErrMalformed
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		kind = ErrInvalidSignature
+	case errors.Is(err, jwt.ErrTokenExpired):
+		kind = ErrExpired
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		kind = ErrNotValidYet
+	}
+	return newTokenError(kind, token, err)
+}
@@ -0,0 +1,79 @@
+package jwt
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/weloe/token-go/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenError(t *testing.T) {
+	enforcer, clock := newTestClockEnforcer(t, LegacyClaims)
+	token, err := enforcer.LoginByModel("1", &model.Login{Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+
+	other := newTestEnforcer(t)
+	other.SetSecretKey("456")
+	otherType := newTestEnforcer(t)
+	otherType.SetSecretKey("123")
+	otherType.SetType("admin")
+
+	tests := []struct {
+		name     string
+		enforcer *StatelessEnforcer
+		token    string
+		want     error
+	}{
+		{"empty", enforcer, "", ErrMalformed},
+		{"malformed", enforcer, "a.b", ErrMalformed},
+		{"signature", other, token, ErrInvalidSignature},
+		{"login type", otherType, token, ErrLoginTypeMismatch},
+	}
+	for _, tt := range tests {
+		_, err = tt.enforcer.GetIdByToken(tt.token)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%v: GetIdByToken() error = %v, want %v", tt.name, err, tt.want)
+		}
+		if tt.token != "" && strings.Contains(err.Error(), tt.token) {
+			t.Errorf("%v: error message should not contain token: %v", tt.name, err)
+		}
+	}
+
+	clock.Add(61 * time.Second)
+	_, err = enforcer.GetIdByToken(token)
+	if !errors.Is(err, ErrExpired) || !errors.Is(err, jwt.ErrTokenExpired) {
+		t.Errorf("GetIdByToken() error = %v, want ErrExpired", err)
+	}
+	var tokenErr *TokenError
+	if !errors.As(err, &tokenErr) || tokenErr.Kind != ErrExpired || tokenErr.Token != "***"+token[len(token)-6:] {
+		t.Errorf("errors.As() failed: %+v", tokenErr)
+	}
+	if strings.Contains(err.Error(), token) {
+		t.Errorf("error message should not contain token: %v", err)
+	}
+}
+
+func TestTokenError_TokenType(t *testing.T) {
+	enforcer := newTestAdapterEnforcer(t)
+	pair, err := enforcer.LoginWithRefreshToken("1", &model.Login{Timeout: 60}, 120, nil)
+	if err != nil {
+		t.Fatalf("LoginWithRefreshToken() failed: %v", err)
+	}
+	if _, err = enforcer.GetIdByToken(pair.RefreshToken); !errors.Is(err, ErrTokenTypeMismatch) {
+		t.Errorf("GetIdByToken() error = %v, want ErrTokenTypeMismatch", err)
+	}
+	if _, err = enforcer.Refresh(pair.AccessToken); !errors.Is(err, ErrTokenTypeMismatch) {
+		t.Errorf("Refresh() error = %v, want ErrTokenTypeMismatch", err)
+	}
+
+	if err = enforcer.RevokeToken(pair.AccessToken); err != nil {
+		t.Fatalf("RevokeToken() failed: %v", err)
+	}
+	if _, err = enforcer.GetIdByToken(pair.AccessToken); !errors.Is(err, ErrRevoked) {
+		t.Errorf("GetIdByToken() error = %v, want ErrRevoked", err)
+	}
+}
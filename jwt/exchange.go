@@ -0,0 +1,224 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/weloe/token-go/model"
+	"strings"
+)
+
+// ACTOR RFC 8693 act claim, the party acting on behalf of the subject.
+// Nested act claim is the prior actor of the delegation chain
+const ACTOR = "act"
+
+// ExchangeOptions RFC 8693 style token exchange request
+type ExchangeOptions struct {
+	// SubjectToken token of the subject, derived token keeps its loginId and claims.
+	// One of SubjectToken and SubjectId must be set
+	SubjectToken string
+	// SubjectId loginId of the subject when actor acts as a user without user's token, such as support staff.
+	// Authorize is required
+	SubjectId string
+	// ActorToken token of the actor, verified and recorded in act claim
+	ActorToken string
+	// ActorLoginType login type of ActorToken, default is the login type of enforcer
+	ActorLoginType string
+	// Scopes narrowed scopes, must be a subset of the scopes of subject, keep subject scopes if empty
+	Scopes []string
+	// Roles narrowed roles, must be a subset of the roles of subject, keep subject roles if empty
+	Roles []string
+	// Permissions narrowed permissions, each must be granted to subject, keep subject permissions if empty
+	Permissions []string
+	// Authorize check the actor can act on behalf of the subject, return error to reject.
+	// Exchange by SubjectId fails if it is nil
+	Authorize func(actor *Actor, subjectId string) error
+	// Timeout seconds, derived token can not outlive subject token or actor token, default is 5 minutes
+	Timeout int64
+}
+
+func (s *StatelessEnforcer) initExchangeOptions(options *ExchangeOptions) {
+	if options.ActorLoginType == "" {
+		options.ActorLoginType = s.GetType()
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 60 * 5
+	}
+}
+
+// Actor the party acting on behalf of the subject
+type Actor struct {
+	Id        string
+	LoginType string
+	// Actor prior actor of the delegation chain, nil if none
+	Actor *Actor
+}
+
+// Exchange mint a derived token for the subject with act claim which records the actor chain
+func (s *StatelessEnforcer) Exchange(options ExchangeOptions) (string, error) {
+	if (options.SubjectToken == "") == (options.SubjectId == "") {
+		return "", errors.New("one of SubjectToken and SubjectId must be set")
+	}
+	if options.ActorToken == "" {
+		return "", errors.New("arg ActorToken can not be nil")
+	}
+	if options.SubjectId != "" && options.Authorize == nil {
+		return "", errors.New("exchange by SubjectId needs arg Authorize")
+	}
+	s.initExchangeOptions(&options)
+	now := s.validator.now()
+
+	actorPayloads, err := s.parseTokenByType(options.ActorToken, options.ActorLoginType)
+	if err != nil {
+		return "", err
+	}
+	actorId, err := getIdFromClaims(options.ActorToken, actorPayloads)
+	if err != nil {
+		return "", err
+	}
+	timeout := minTimeout(options.Timeout, actorPayloads, now.UnixMilli())
+
+	actor := &Actor{Id: actorId, LoginType: options.ActorLoginType, Actor: getActor(actorPayloads[ACTOR])}
+
+	var claims jwt.MapClaims
+	if options.SubjectToken != "" {
+		payloads, err := s.parseToken(options.SubjectToken, true)
+		if err != nil {
+			return "", err
+		}
+		timeout = minTimeout(timeout, payloads, now.UnixMilli())
+		claims, err = reissueClaims(payloads, timeout, now)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		device, _ := actorPayloads[DEVICE].(string)
+		claims, err = s.newClaims(options.SubjectId, device, timeout, nil)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	subjectId, _ := claimLoginId(claims)
+	if options.Authorize != nil {
+		err = options.Authorize(actor, subjectId)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if len(options.Scopes) > 0 {
+		if err = narrowAuthClaims(claims, SCOPE, options.Scopes, matchStr); err != nil {
+			return "", err
+		}
+		claims[SCOPE] = strings.Join(options.Scopes, " ")
+	}
+	if len(options.Roles) > 0 {
+		if err = narrowAuthClaims(claims, ROLES, options.Roles, matchStr); err != nil {
+			return "", err
+		}
+		claims[ROLES] = options.Roles
+	}
+	if len(options.Permissions) > 0 {
+		if err = narrowAuthClaims(claims, PERMISSIONS, options.Permissions, matchPermission); err != nil {
+			return "", err
+		}
+		claims[PERMISSIONS] = options.Permissions
+	}
+
+	act := map[string]interface{}{
+		SUBJECT:    actorId,
+		LOGIN_TYPE: options.ActorLoginType,
+	}
+	// the previous actor of subject token becomes the prior actor
+	if prior, ok := claims[ACTOR]; ok {
+		act[ACTOR] = prior
+	}
+	claims[ACTOR] = act
+
+	token, err := s.generateToken(claims)
+	if err != nil {
+		return "", err
+	}
+	if s.stateful {
+		device, _ := claims[DEVICE].(string)
+		_, err = s.loginSession(subjectId, token, &model.Login{Device: device, Timeout: timeout}, nil)
+		if err != nil {
+			return "", err
+		}
+	}
+	return token, nil
+}
+
+// GetSubjectByToken parse token and get the loginId of subject, same as GetIdByToken
+func (s *StatelessEnforcer) GetSubjectByToken(token string) (string, error) {
+	return s.GetIdByToken(token)
+}
+
+// GetActorByToken parse token and get the actor, return nil if the token is not derived by Exchange
+func (s *StatelessEnforcer) GetActorByToken(token string) (*Actor, error) {
+	payloads, err := s.parseToken(token, true)
+	if err != nil {
+		return nil, err
+	}
+	return getActor(payloads[ACTOR]), nil
+}
+
+// parseTokenByType parse token of loginType with keys and server side state
+func (s *StatelessEnforcer) parseTokenByType(token string, loginType string) (jwt.MapClaims, error) {
+	if loginType == s.GetType() {
+		return s.parseToken(token, true)
+	}
+	payloads, err := s.parseTokenByKey(token, loginType, true)
+	if err != nil {
+		return nil, err
+	}
+	err = s.checkRevoked(payloads)
+	if err != nil {
+		return nil, err
+	}
+	return payloads, nil
+}
+
+// getActor convert act claim to Actor
+func getActor(value interface{}) *Actor {
+	act, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	id, _ := act[SUBJECT].(string)
+	loginType, _ := act[LOGIN_TYPE].(string)
+	return &Actor{
+		Id:        id,
+		LoginType: loginType,
+		Actor:     getActor(act[ACTOR]),
+	}
+}
+
+// narrowAuthClaims check every narrowed value is granted to subject
+func narrowAuthClaims(claims jwt.MapClaims, key string, narrowed []string, match func(granted string, required string) bool) error {
+	granted := getAuthClaims(claims, key)
+	for _, value := range narrowed {
+		if !hasAuthClaim(granted, value, match) {
+			return fmt.Errorf("%v %v is not granted to subject", key, value)
+		}
+	}
+	return nil
+}
+
+// minTimeout limit timeout by the remaining life of token, at least 1 second.
+// Token expired within leeway has no remaining life, a non-positive timeout means never expire in claims
+func minTimeout(timeout int64, payloads jwt.MapClaims, nowMilli int64) int64 {
+	exp, ok := claimExpiration(payloads)
+	if !ok || exp <= NEVER_EXPIRE {
+		return timeout
+	}
+	remain := (exp - nowMilli) / 1000
+	if remain < 1 {
+		remain = 1
+	}
+	if remain < timeout {
+		return remain
+	}
+	return timeout
+}
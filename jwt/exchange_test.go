@@ -0,0 +1,206 @@
+package jwt
+
+import (
+	"errors"
+	"github.com/weloe/token-go/model"
+	"testing"
+	"time"
+)
+
+func TestStatelessEnforcer_Exchange(t *testing.T) {
+	enforcer := newTestEnforcer(t)
+	enforcer.SetSecretKey("123")
+	enforcer.SetAuth(&testAuthManager{})
+
+	user, err := enforcer.LoginByModel("1", &model.Login{Device: "pc", Timeout: 600}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	serviceA, err := enforcer.LoginByModel("service-a", &model.Login{Timeout: 600}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	serviceB, err := enforcer.LoginByModel("service-b", &model.Login{Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+
+	if _, err = enforcer.Exchange(ExchangeOptions{ActorToken: serviceA}); err == nil {
+		t.Errorf("Exchange() should require subject")
+	}
+	if _, err = enforcer.Exchange(ExchangeOptions{SubjectToken: user, ActorToken: serviceA, Scopes: []string{"email"}}); err == nil {
+		t.Errorf("Exchange() should reject scope which is not granted")
+	}
+
+	derived, err := enforcer.Exchange(ExchangeOptions{SubjectToken: user, ActorToken: serviceA, Scopes: []string{"openid"}})
+	if err != nil {
+		t.Fatalf("Exchange() failed: %v", err)
+	}
+	if id, err := enforcer.GetSubjectByToken(derived); err != nil || id != "1" {
+		t.Errorf("GetSubjectByToken() failed: id = %v, err = %v", id, err)
+	}
+	if scopes, err := enforcer.GetScopesByToken(derived); err != nil || len(scopes) != 1 || scopes[0] != "openid" {
+		t.Errorf("GetScopesByToken() failed: scopes = %v, err = %v", scopes, err)
+	}
+	if timeout, err := enforcer.GetTokenTimeout(derived); err != nil || timeout < 299 || timeout > 300 {
+		t.Errorf("GetTokenTimeout() failed: timeout = %v, err = %v", timeout, err)
+	}
+	actor, err := enforcer.GetActorByToken(derived)
+	if err != nil || actor == nil || actor.Id != "service-a" || actor.LoginType != "user" || actor.Actor != nil {
+		t.Errorf("GetActorByToken() failed: actor = %+v, err = %v", actor, err)
+	}
+
+	// service b calls downstream on behalf of user via service a, lifetime is limited by service b token
+	chained, err := enforcer.Exchange(ExchangeOptions{SubjectToken: derived, ActorToken: serviceB})
+	if err != nil {
+		t.Fatalf("Exchange() failed: %v", err)
+	}
+	actor, err = enforcer.GetActorByToken(chained)
+	if err != nil || actor == nil || actor.Id != "service-b" || actor.Actor == nil || actor.Actor.Id != "service-a" {
+		t.Errorf("GetActorByToken() chain failed: actor = %+v, err = %v", actor, err)
+	}
+	if timeout, err := enforcer.GetTokenTimeout(chained); err != nil || timeout > 60 {
+		t.Errorf("GetTokenTimeout() failed: timeout = %v, err = %v", timeout, err)
+	}
+
+	if actor, err = enforcer.GetActorByToken(user); err != nil || actor != nil {
+		t.Errorf("GetActorByToken() should return nil for login token: actor = %+v, err = %v", actor, err)
+	}
+}
+
+func TestStatelessEnforcer_ExchangeImpersonate(t *testing.T) {
+	enforcer := newTestEnforcer(t)
+	enforcer.SetSecretKey("123")
+
+	staff, err := enforcer.LoginByModel("staff", &model.Login{Device: "pc", Timeout: 600}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	user, err := enforcer.LoginByModel("7", &model.Login{Device: "pc", Timeout: 600}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	authorize := func(actor *Actor, subjectId string) error {
+		if actor.Id != "staff" {
+			return errors.New("only staff can impersonate")
+		}
+		return nil
+	}
+	if _, err = enforcer.Exchange(ExchangeOptions{SubjectId: "42", ActorToken: staff}); err == nil {
+		t.Fatalf("Exchange() by SubjectId should fail without Authorize")
+	}
+	if _, err = enforcer.Exchange(ExchangeOptions{SubjectId: "42", ActorToken: user, Authorize: authorize}); err == nil {
+		t.Fatalf("Exchange() should fail when Authorize rejects actor")
+	}
+	token, err := enforcer.Exchange(ExchangeOptions{SubjectId: "42", ActorToken: staff, Timeout: 60, Authorize: authorize})
+	if err != nil {
+		t.Fatalf("Exchange() failed: %v", err)
+	}
+	if id, err := enforcer.GetSubjectByToken(token); err != nil || id != "42" {
+		t.Errorf("GetSubjectByToken() failed: id = %v, err = %v", id, err)
+	}
+	if actor, err := enforcer.GetActorByToken(token); err != nil || actor == nil || actor.Id != "staff" {
+		t.Errorf("GetActorByToken() failed: actor = %+v, err = %v", actor, err)
+	}
+	if timeout, err := enforcer.GetTokenTimeout(token); err != nil || timeout < 59 || timeout > 60 {
+		t.Errorf("GetTokenTimeout() failed: timeout = %v, err = %v", timeout, err)
+	}
+}
+
+func TestStatelessEnforcer_ExchangeNarrowAuthClaims(t *testing.T) {
+	enforcer := newTestEnforcer(t)
+	enforcer.SetSecretKey("123")
+	enforcer.SetAuth(&testAuthManager{})
+
+	user, err := enforcer.LoginByModel("1", &model.Login{Timeout: 600}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	service, err := enforcer.LoginByModel("service", &model.Login{Timeout: 600}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	if _, err = enforcer.Exchange(ExchangeOptions{SubjectToken: user, ActorToken: service, Roles: []string{"root"}}); err == nil {
+		t.Errorf("Exchange() should reject role which is not granted")
+	}
+	if _, err = enforcer.Exchange(ExchangeOptions{SubjectToken: user, ActorToken: service, Permissions: []string{"goods:delete"}}); err == nil {
+		t.Errorf("Exchange() should reject permission which is not granted")
+	}
+	derived, err := enforcer.Exchange(ExchangeOptions{SubjectToken: user, ActorToken: service, Roles: []string{"user"}, Permissions: []string{"user:read"}})
+	if err != nil {
+		t.Fatalf("Exchange() failed: %v", err)
+	}
+	if roles, err := enforcer.GetRolesByToken(derived); err != nil || len(roles) != 1 || roles[0] != "user" {
+		t.Errorf("GetRolesByToken() = %v, err = %v, want [user]", roles, err)
+	}
+	if permissions, err := enforcer.GetPermissionsByToken(derived); err != nil || len(permissions) != 1 || permissions[0] != "user:read" {
+		t.Errorf("GetPermissionsByToken() = %v, err = %v, want [user:read]", permissions, err)
+	}
+}
+
+func TestStatelessEnforcer_ExchangeNotRenewed(t *testing.T) {
+	enforcer, clock := newTestClockEnforcer(t, RegisteredClaims)
+	enforcer.SetRenewalOptions(RenewalOptions{Threshold: 240, Timeout: 60 * 60 * 24 * 30, IsWriteHeader: true})
+
+	user, err := enforcer.LoginByModel("1", &model.Login{Timeout: 600}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	derived, err := enforcer.Exchange(ExchangeOptions{SubjectToken: user, ActorToken: user, Timeout: 300})
+	if err != nil {
+		t.Fatalf("Exchange() failed: %v", err)
+	}
+	clock.Add(2 * time.Minute)
+	c, recorder := newTestRequestContext(t, enforcer, derived)
+	if _, err = enforcer.GetLoginId(c); err != nil {
+		t.Fatalf("GetLoginId() failed: %v", err)
+	}
+	if renewed := recorder.Header().Get(enforcer.GetTokenConfig().TokenName); renewed != "" {
+		t.Errorf("derived token should not be renewed")
+	}
+}
+
+func TestStatelessEnforcer_ExchangeExpiredWithinLeeway(t *testing.T) {
+	for _, mode := range []ClaimsMode{LegacyClaims, RegisteredClaims} {
+		enforcer, clock := newTestClockEnforcer(t, mode)
+		enforcer.SetLeeway(30 * time.Second)
+
+		user, err := enforcer.LoginByModel("1", &model.Login{Timeout: 60}, nil)
+		if err != nil {
+			t.Fatalf("LoginByModel() failed: %v", err)
+		}
+		service, err := enforcer.LoginByModel("service-a", &model.Login{Timeout: 600}, nil)
+		if err != nil {
+			t.Fatalf("LoginByModel() failed: %v", err)
+		}
+		// subject token expired 5s ago, still accepted within leeway
+		clock.Add(65 * time.Second)
+		derived, err := enforcer.Exchange(ExchangeOptions{SubjectToken: user, ActorToken: service, Timeout: 300})
+		if err != nil {
+			t.Fatalf("mode %v Exchange() failed: %v", mode, err)
+		}
+		if timeout, err := enforcer.GetTokenTimeout(derived); err != nil || timeout != 1 {
+			t.Errorf("mode %v GetTokenTimeout() = %v, err = %v, want 1", mode, timeout, err)
+		}
+		clock.Add(time.Hour)
+		if _, err = enforcer.GetIdByToken(derived); !errors.Is(err, ErrExpired) {
+			t.Errorf("mode %v GetIdByToken() error = %v, want ErrExpired", mode, err)
+		}
+
+		// actor token expired within leeway
+		service, err = enforcer.LoginByModel("service-a", &model.Login{Timeout: 60}, nil)
+		if err != nil {
+			t.Fatalf("LoginByModel() failed: %v", err)
+		}
+		clock.Add(65 * time.Second)
+		derived, err = enforcer.Exchange(ExchangeOptions{SubjectId: "2", ActorToken: service, Authorize: func(actor *Actor, subjectId string) error {
+			return nil
+		}})
+		if err != nil {
+			t.Fatalf("mode %v Exchange() failed: %v", mode, err)
+		}
+		if timeout, err := enforcer.GetTokenTimeout(derived); err != nil || timeout != 1 {
+			t.Errorf("mode %v GetTokenTimeout() = %v, err = %v, want 1", mode, timeout, err)
+		}
+	}
+}
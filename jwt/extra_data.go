@@ -0,0 +1,102 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var _ ClaimsReader = (IEnforcer)(nil)
+var _ ClaimsReader = (*RemoteVerifier)(nil)
+
+// ClaimsReader parse and verify token, return claims.
+// It is implemented by IEnforcer and RemoteVerifier
+type ClaimsReader interface {
+	GetClaimsByToken(token string) (jwt.Claims, error)
+}
+
+// GetClaimsAs parse token and decode all claims into T, T is usually a struct with json tags.
+// T can declare part of the claims, other claims are ignored
+func GetClaimsAs[T any](reader ClaimsReader, token string) (T, error) {
+	var result T
+	mapClaims, err := readMapClaims(reader, token)
+	if err != nil {
+		return result, err
+	}
+	err = decodeAs(map[string]interface{}(mapClaims), &result, false)
+	if err != nil {
+		return result, fmt.Errorf("decode claims failed: %v", err)
+	}
+	return result, nil
+}
+
+// GetExtraDataAs parse token and decode the value of extraData map by key into T.
+// Return error if the key does not exist or the type does not match, such as a field which T does not have
+func GetExtraDataAs[T any](reader ClaimsReader, token string, key string) (T, error) {
+	var result T
+	mapClaims, err := readMapClaims(reader, token)
+	if err != nil {
+		return result, err
+	}
+	value, err := getExtraDataFromClaims(mapClaims, key)
+	if err != nil {
+		return result, err
+	}
+	if value == nil {
+		return result, fmt.Errorf("extraData key %v does not exist", key)
+	}
+	err = decodeAs(value, &result, true)
+	if err != nil {
+		return result, fmt.Errorf("decode extraData %v failed: %v", key, err)
+	}
+	return result, nil
+}
+
+// GetAllExtraDataAs parse token and decode the whole extraData map into T.
+// Return error if extraData has a field which T does not have
+func GetAllExtraDataAs[T any](reader ClaimsReader, token string) (T, error) {
+	var result T
+	mapClaims, err := readMapClaims(reader, token)
+	if err != nil {
+		return result, err
+	}
+	extraData, ok := mapClaims[EXTRA_DATA]
+	if !ok || extraData == nil {
+		return result, fmt.Errorf("extraData does not exist")
+	}
+	err = decodeAs(extraData, &result, true)
+	if err != nil {
+		return result, fmt.Errorf("decode extraData failed: %v", err)
+	}
+	return result, nil
+}
+
+func readMapClaims(reader ClaimsReader, token string) (jwt.MapClaims, error) {
+	if reader == nil {
+		return nil, fmt.Errorf("arg reader can not be nil")
+	}
+	claims, err := reader.GetClaimsByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	mapClaims, ok := claims.(jwt.MapClaims)
+	if !ok {
+		return nil, fmt.Errorf("unexpected claims type: %T", claims)
+	}
+	return mapClaims, nil
+}
+
+// decodeAs convert the json decoded value into out by json round trip.
+// If strict is true, unknown fields of struct are rejected
+func decodeAs(value interface{}, out interface{}, strict bool) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	return decoder.Decode(out)
+}
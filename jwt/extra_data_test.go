@@ -0,0 +1,74 @@
+package jwt
+
+import (
+	"github.com/weloe/token-go/model"
+	"testing"
+)
+
+type testProfile struct {
+	Name  string   `json:"name"`
+	Age   int      `json:"age"`
+	Roles []string `json:"roles"`
+}
+
+func TestGetExtraDataAs(t *testing.T) {
+	enforcer := newTestEnforcer(t)
+	enforcer.SetSecretKey("123")
+
+	token, err := enforcer.LoginByModel("1", &model.Login{
+		Timeout: 60,
+		JwtData: map[string]interface{}{
+			"count":   42,
+			"profile": testProfile{Name: "weloe", Age: 18, Roles: []string{"admin"}},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+
+	count, err := GetExtraDataAs[int](enforcer, token, "count")
+	if err != nil || count != 42 {
+		t.Errorf("GetExtraDataAs[int]() failed: count = %v, err = %v", count, err)
+	}
+	profile, err := GetExtraDataAs[testProfile](enforcer, token, "profile")
+	if err != nil || profile.Name != "weloe" || profile.Age != 18 || len(profile.Roles) != 1 {
+		t.Errorf("GetExtraDataAs[testProfile]() failed: profile = %+v, err = %v", profile, err)
+	}
+	if _, err = GetExtraDataAs[string](enforcer, token, "count"); err == nil {
+		t.Errorf("GetExtraDataAs[string]() should fail on number")
+	}
+	if _, err = GetExtraDataAs[struct {
+		Title string `json:"title"`
+	}](enforcer, token, "profile"); err == nil {
+		t.Errorf("GetExtraDataAs[struct]() should fail on struct whose fields do not match")
+	}
+	if _, err = GetAllExtraDataAs[struct {
+		Count int `json:"count"`
+	}](enforcer, token); err == nil {
+		t.Errorf("GetAllExtraDataAs() should fail on struct which does not have profile")
+	}
+	if _, err = GetExtraDataAs[int](enforcer, token, "none"); err == nil {
+		t.Errorf("GetExtraDataAs[int]() should fail on missing key")
+	}
+
+	all, err := GetAllExtraDataAs[struct {
+		Count   int         `json:"count"`
+		Profile testProfile `json:"profile"`
+	}](enforcer, token)
+	if err != nil || all.Count != 42 || all.Profile.Name != "weloe" {
+		t.Errorf("GetAllExtraDataAs() failed: all = %+v, err = %v", all, err)
+	}
+
+	claims, err := GetClaimsAs[struct {
+		LoginId   string `json:"loginId"`
+		LoginType string `json:"loginType"`
+		Eff       int64  `json:"eff"`
+	}](enforcer, token)
+	if err != nil || claims.LoginId != "1" || claims.LoginType != "user" || claims.Eff <= 0 {
+		t.Errorf("GetClaimsAs() failed: claims = %+v, err = %v", claims, err)
+	}
+
+	if _, err = GetClaimsAs[map[string]interface{}](enforcer, "invalid"); err == nil {
+		t.Errorf("GetClaimsAs() should fail on invalid token")
+	}
+}
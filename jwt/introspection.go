@@ -0,0 +1,140 @@
+package jwt
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// CLIENT_ID client_id claim, the client which token is issued to
+const CLIENT_ID = "client_id"
+
+// IntrospectionOptions caller authentication of introspection endpoint, one of Clients and SharedSecret must be set
+type IntrospectionOptions struct {
+	// Clients client id and secret of callers, authenticated by basic auth
+	Clients map[string]string
+	// SharedSecret authenticated by Authorization: Bearer <SharedSecret>
+	SharedSecret string
+}
+
+// IntrospectionResponse RFC 7662 introspection response, only Active is set if token is not active
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientId  string `json:"client_id,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	// Exp Iat seconds
+	Exp       int64                  `json:"exp,omitempty"`
+	Iat       int64                  `json:"iat,omitempty"`
+	Sub       string                 `json:"sub,omitempty"`
+	Aud       []string               `json:"aud,omitempty"`
+	Iss       string                 `json:"iss,omitempty"`
+	Jti       string                 `json:"jti,omitempty"`
+	LoginType string                 `json:"loginType,omitempty"`
+	Device    string                 `json:"device,omitempty"`
+	Act       interface{}            `json:"act,omitempty"`
+	ExtraData map[string]interface{} `json:"extraData,omitempty"`
+	// Cnf jkt of the key which DPoP-bound token is bound to, see RFC 9449 section 6.2
+	Cnf map[string]interface{} `json:"cnf,omitempty"`
+}
+
+// NewIntrospectionHandler serve RFC 7662 token introspection, accept POST form with token parameter.
+// Token is parsed by enforcer, so revoked, kicked out and expired token is not active.
+// client_id is the client_id claim, default is device
+func NewIntrospectionHandler(enforcer *StatelessEnforcer, options IntrospectionOptions) (http.Handler, error) {
+	if enforcer == nil {
+		return nil, errors.New("arg enforcer can not be nil")
+	}
+	if len(options.Clients) == 0 && options.SharedSecret == "" {
+		return nil, errors.New("one of Clients and SharedSecret must be set")
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !authenticateIntrospection(r, options) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="introspection"`)
+			writeIntrospection(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+			return
+		}
+		token := r.PostFormValue("token")
+		if token == "" {
+			writeIntrospection(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+			return
+		}
+		writeIntrospection(w, http.StatusOK, enforcer.Introspect(token))
+	}), nil
+}
+
+// Introspect parse token and build RFC 7662 introspection response
+func (s *StatelessEnforcer) Introspect(token string) *IntrospectionResponse {
+	payloads, err := s.parseToken(token, true)
+	if err != nil {
+		return &IntrospectionResponse{Active: false}
+	}
+	id, err := getIdFromClaims(token, payloads)
+	if err != nil {
+		return &IntrospectionResponse{Active: false}
+	}
+
+	response := &IntrospectionResponse{
+		Active:    true,
+		Scope:     strings.Join(getAuthClaims(payloads, SCOPE), " "),
+		TokenType: "Bearer",
+		Sub:       id,
+		Jti:       getJti(payloads),
+		Act:       payloads[ACTOR],
+	}
+	response.LoginType, _ = payloads[LOGIN_TYPE].(string)
+	response.Device, _ = payloads[DEVICE].(string)
+	response.ClientId, _ = payloads[CLIENT_ID].(string)
+	if response.ClientId == "" {
+		response.ClientId = response.Device
+	}
+	if exp, ok := claimExpiration(payloads); ok && exp > NEVER_EXPIRE {
+		response.Exp = exp / 1000
+	}
+	if iat, err := payloads.GetIssuedAt(); err == nil && iat != nil {
+		response.Iat = iat.Unix()
+	}
+	response.Iss, _ = payloads.GetIssuer()
+	response.Aud, _ = payloads.GetAudience()
+	response.ExtraData, _ = payloads[EXTRA_DATA].(map[string]interface{})
+	if jkt := getJkt(payloads); jkt != "" {
+		response.TokenType = "DPoP"
+		response.Cnf = map[string]interface{}{JKT: jkt}
+	}
+	return response
+}
+
+// authenticateIntrospection authenticate caller by basic auth or shared secret
+func authenticateIntrospection(r *http.Request, options IntrospectionOptions) bool {
+	if clientId, secret, ok := r.BasicAuth(); ok {
+		expected, exist := options.Clients[clientId]
+		return exist && subtle.ConstantTimeCompare([]byte(secret), []byte(expected)) == 1
+	}
+	if options.SharedSecret == "" {
+		return false
+	}
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(authorization, "Bearer ")), []byte(options.SharedSecret)) == 1
+}
+
+func writeIntrospection(w http.ResponseWriter, status int, body interface{}) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
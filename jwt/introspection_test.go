@@ -0,0 +1,128 @@
+package jwt
+
+import (
+	"encoding/json"
+	"github.com/weloe/token-go/model"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func postTestIntrospection(t *testing.T, handler http.Handler, token string, auth func(r *http.Request)) (int, map[string]interface{}) {
+	form := url.Values{}
+	if token != "" {
+		form.Set("token", token)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/introspect", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if auth != nil {
+		auth(req)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	body := make(map[string]interface{})
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("json.Unmarshal() failed: %v", err)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("introspection handler failed: unexpected Cache-Control %v", cc)
+	}
+	return rec.Code, body
+}
+
+func TestNewIntrospectionHandler(t *testing.T) {
+	enforcer := newTestAdapterEnforcer(t)
+	if _, err := NewIntrospectionHandler(enforcer, IntrospectionOptions{}); err == nil {
+		t.Errorf("NewIntrospectionHandler() should require caller authentication")
+	}
+	if _, err := NewIntrospectionHandler(nil, IntrospectionOptions{SharedSecret: "s"}); err == nil {
+		t.Errorf("NewIntrospectionHandler() should reject nil enforcer")
+	}
+}
+
+func TestIntrospectionHandler(t *testing.T) {
+	enforcer := newTestAdapterEnforcer(t)
+	enforcer.SetAuth(&testAuthManager{})
+	handler, err := NewIntrospectionHandler(enforcer, IntrospectionOptions{
+		Clients:      map[string]string{"gateway": "gateway-secret"},
+		SharedSecret: "shared-secret",
+	})
+	if err != nil {
+		t.Fatalf("NewIntrospectionHandler() failed: %v", err)
+	}
+	basicAuth := func(r *http.Request) { r.SetBasicAuth("gateway", "gateway-secret") }
+	bearer := func(r *http.Request) { r.Header.Set("Authorization", "Bearer shared-secret") }
+
+	token, err := enforcer.LoginByModel("1", &model.Login{Device: "mobile", Timeout: 60, JwtData: map[string]interface{}{"name": "weloe"}}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+
+	for _, auth := range []func(r *http.Request){basicAuth, bearer} {
+		code, body := postTestIntrospection(t, handler, token, auth)
+		if code != http.StatusOK || body["active"] != true || body["sub"] != "1" || body["client_id"] != "mobile" ||
+			body["scope"] != "openid profile" || body["exp"] == nil {
+			t.Errorf("unexpected introspection response: %v %v", code, body)
+		}
+		if extraData, _ := body["extraData"].(map[string]interface{}); extraData["name"] != "weloe" {
+			t.Errorf("unexpected extraData: %v", body["extraData"])
+		}
+	}
+
+	for name, auth := range map[string]func(r *http.Request){
+		"none":         nil,
+		"wrong secret": func(r *http.Request) { r.SetBasicAuth("gateway", "shared-secret") },
+		"wrong client": func(r *http.Request) { r.SetBasicAuth("other", "gateway-secret") },
+		"wrong bearer": func(r *http.Request) { r.Header.Set("Authorization", "Bearer gateway-secret") },
+		"wrong scheme": func(r *http.Request) { r.Header.Set("Authorization", "shared-secret") },
+	} {
+		if code, body := postTestIntrospection(t, handler, token, auth); code != http.StatusUnauthorized || body["error"] != "invalid_client" {
+			t.Errorf("%v: unexpected response %v %v", name, code, body)
+		}
+	}
+
+	if code, body := postTestIntrospection(t, handler, "", basicAuth); code != http.StatusBadRequest || body["error"] != "invalid_request" {
+		t.Errorf("unexpected response for missing token: %v %v", code, body)
+	}
+
+	// revoked token is not active, no other member is returned
+	if err = enforcer.RevokeToken(token); err != nil {
+		t.Fatalf("RevokeToken() failed: %v", err)
+	}
+	if code, body := postTestIntrospection(t, handler, token, basicAuth); code != http.StatusOK || body["active"] != false || len(body) != 1 {
+		t.Errorf("unexpected response for revoked token: %v %v", code, body)
+	}
+	if code, body := postTestIntrospection(t, handler, "invalid", basicAuth); code != http.StatusOK || body["active"] != false {
+		t.Errorf("unexpected response for invalid token: %v %v", code, body)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/introspect?token="+token, nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("unexpected status for GET: %v", rec.Code)
+	}
+}
+
+func TestStatelessEnforcer_IntrospectDPoP(t *testing.T) {
+	const loginURL = "https://example.com/login"
+	key := newTestProofKey(t)
+	enforcer := newTestAdapterEnforcer(t)
+	c := newTestProofContext(enforcer, "POST", loginURL, "", key.proof(t, "POST", loginURL, "", time.Now()))
+	token, err := enforcer.LoginWithProof("1", model.DefaultLoginModel(), c)
+	if err != nil {
+		t.Fatalf("LoginWithProof() failed: %v", err)
+	}
+	response := enforcer.Introspect(token)
+	if !response.Active || response.TokenType != "DPoP" || response.Cnf[JKT] != key.jkt {
+		t.Errorf("Introspect() = %+v, want cnf.jkt %v", response, key.jkt)
+	}
+
+	unbound, _ := enforcer.Login("1", nil)
+	if response = enforcer.Introspect(unbound); response.TokenType != "Bearer" || response.Cnf != nil {
+		t.Errorf("Introspect() = %+v, want Bearer without cnf", response)
+	}
+}
@@ -0,0 +1,147 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"strings"
+)
+
+/*
+	JWE mode, the signed token is encrypted as nested JWT, see RFC 7519 section 5.2
+*/
+
+// jweHeader the protected header of JWE which is checked before decryption
+type jweHeader struct {
+	Alg string `json:"alg"`
+	Enc string `json:"enc"`
+	Zip string `json:"zip"`
+	Cty string `json:"cty"`
+}
+
+// SetEncryptionKey enable JWE mode, token is signed and then encrypted by key,
+// claims and extraData are not readable by clients. Set nil to disable
+func (s *StatelessEnforcer) SetEncryptionKey(key EncryptionKey) {
+	s.encryptionKey = key
+	s.tokenCache.clear()
+}
+
+// GetEncryptionKey return nil if JWE mode is disabled
+func (s *StatelessEnforcer) GetEncryptionKey() EncryptionKey {
+	return s.encryptionKey
+}
+
+// generateToken sign claims with the keys of enforcer, encrypt the signed token in JWE mode
+func (s *StatelessEnforcer) generateToken(claims jwt.MapClaims) (string, error) {
+	token, err := generateToken(claims, s.keys())
+	if err != nil {
+		return "", err
+	}
+	if s.encryptionKey == nil {
+		return token, nil
+	}
+	return encryptToken(token, s.encryptionKey)
+}
+
+// parseTokenByKey decrypt token in JWE mode, then parse access token with the keys of enforcer.
+// Verified token is cached if token cache is enabled, only claims are validated again on cache hit
+func (s *StatelessEnforcer) parseTokenByKey(token string, loginType string, isCheckTimeout bool) (jwt.MapClaims, error) {
+	if payloads, ok := s.tokenCache.get(token, loginType, s.validator.now()); ok {
+		err := s.validator.validate(token, payloads, isCheckTimeout)
+		if err != nil {
+			return nil, classifyError(token, err)
+		}
+		return payloads, nil
+	}
+
+	signed, err := s.decryptToken(token)
+	if err != nil {
+		return nil, err
+	}
+	payloads, err := parseTokenByKey(signed, loginType, s.keys(), isCheckTimeout, s.validator)
+	if err != nil {
+		return nil, withToken(err, token)
+	}
+	s.tokenCache.set(token, loginType, payloads, s.validator.now())
+	return payloads, nil
+}
+
+// verifyToken decrypt token in JWE mode, then verify token with the keys of enforcer
+func (s *StatelessEnforcer) verifyToken(token string, loginType string, isCheckTimeout bool) (jwt.MapClaims, error) {
+	signed, err := s.decryptToken(token)
+	if err != nil {
+		return nil, err
+	}
+	payloads, err := verifyToken(signed, loginType, s.keys(), isCheckTimeout, s.validator)
+	if err != nil {
+		return nil, withToken(err, token)
+	}
+	return payloads, nil
+}
+
+// decryptToken return the signed token, token is returned directly if JWE mode is disabled
+func (s *StatelessEnforcer) decryptToken(token string) (string, error) {
+	if s.encryptionKey == nil {
+		return token, nil
+	}
+	return decryptToken(token, s.encryptionKey)
+}
+
+// encryptToken encrypt signed token as nested JWT
+func encryptToken(token string, key EncryptionKey) (string, error) {
+	if key.EncryptKey() == nil {
+		return "", errors.New("the encryption key can not encrypt token")
+	}
+	encrypter, err := jose.NewEncrypter(key.Encryption(),
+		jose.Recipient{Algorithm: key.Algorithm(), Key: key.EncryptKey()},
+		(&jose.EncrypterOptions{}).WithContentType("JWT"))
+	if err != nil {
+		return "", err
+	}
+	object, err := encrypter.Encrypt([]byte(token))
+	if err != nil {
+		return "", err
+	}
+	return object.CompactSerialize()
+}
+
+// decryptToken check the algorithms of JWE header, decrypt and return the signed token.
+// Compressed JWE is rejected
+func decryptToken(token string, key EncryptionKey) (string, error) {
+	if token == "" {
+		return "", newTokenError(ErrMalformed, token, errors.New("JWE string cannot be null"))
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 {
+		return "", newTokenError(ErrMalformed, token, errors.New("JWE must be compact serialization"))
+	}
+	b, err := jwt.NewParser().DecodeSegment(parts[0])
+	if err != nil {
+		return "", newTokenError(ErrMalformed, token, err)
+	}
+	header := &jweHeader{}
+	err = json.Unmarshal(b, header)
+	if err != nil {
+		return "", newTokenError(ErrMalformed, token, err)
+	}
+	if header.Alg != string(key.Algorithm()) || header.Enc != string(key.Encryption()) {
+		return "", newTokenError(ErrInvalidSignature, token, errors.New("Invalid encryption algorithm: "+header.Alg+" "+header.Enc))
+	}
+	if header.Zip != "" {
+		return "", newTokenError(ErrMalformed, token, errors.New("compressed JWE is not supported"))
+	}
+	if !strings.EqualFold(header.Cty, "JWT") {
+		return "", newTokenError(ErrMalformed, token, errors.New("JWE content must be JWT"))
+	}
+
+	object, err := jose.ParseEncrypted(token)
+	if err != nil {
+		return "", newTokenError(ErrMalformed, token, err)
+	}
+	signed, err := object.Decrypt(key.DecryptKey())
+	if err != nil {
+		return "", newTokenError(ErrInvalidSignature, token, err)
+	}
+	return string(signed), nil
+}
@@ -0,0 +1,145 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/weloe/token-go/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestEncryptionKeys(t *testing.T) map[string]EncryptionKey {
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	directKey, err := NewDirectKey(secret)
+	if err != nil {
+		t.Fatalf("NewDirectKey() failed: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() failed: %v", err)
+	}
+	ecdhKey, err := NewECDHESKey(ecKey)
+	if err != nil {
+		t.Fatalf("NewECDHESKey() failed: %v", err)
+	}
+	return map[string]EncryptionKey{"dir": directKey, "ECDH-ES": ecdhKey}
+}
+
+func TestNewEncryptionKey(t *testing.T) {
+	if _, err := NewDirectKey([]byte("123")); err == nil {
+		t.Errorf("NewDirectKey() should reject short key")
+	}
+	if _, err := NewECDHESKey(nil); err == nil {
+		t.Errorf("NewECDHESKey() should reject nil key")
+	}
+}
+
+func TestStatelessEnforcer_JWE(t *testing.T) {
+	for alg, key := range newTestEncryptionKeys(t) {
+		enforcer := newTestAdapterEnforcer(t)
+		enforcer.SetEncryptionKey(key)
+		clock := &testClock{now: time.Now()}
+		enforcer.SetClock(clock.Now)
+
+		token, err := enforcer.LoginByModel("1", &model.Login{Device: "pc", Timeout: 60, JwtData: map[string]interface{}{"name": "weloe"}}, nil)
+		if err != nil {
+			t.Fatalf("%v LoginByModel() failed: %v", alg, err)
+		}
+		parts := strings.Split(token, ".")
+		if len(parts) != 5 {
+			t.Fatalf("%v token should be JWE compact serialization: %v", alg, token)
+		}
+		for _, part := range parts {
+			b, _ := base64.RawURLEncoding.DecodeString(part)
+			if strings.Contains(string(b), "weloe") {
+				t.Errorf("%v extraData should not be readable: %s", alg, b)
+			}
+		}
+
+		if id, err := enforcer.GetIdByToken(token); err != nil || id != "1" {
+			t.Errorf("%v GetIdByToken() failed: id = %v, err = %v", alg, id, err)
+		}
+		if data, err := enforcer.GetExtraDataByToken(token, "name"); err != nil || data != "weloe" {
+			t.Errorf("%v GetExtraDataByToken() failed: data = %v, err = %v", alg, data, err)
+		}
+		if _, err = enforcer.GetClaimsByToken(token); err != nil {
+			t.Errorf("%v GetClaimsByToken() failed: %v", alg, err)
+		}
+
+		pair, err := enforcer.LoginWithRefreshToken("1", &model.Login{Device: "pc", Timeout: 60}, 600, nil)
+		if err != nil {
+			t.Fatalf("%v LoginWithRefreshToken() failed: %v", alg, err)
+		}
+		if _, err = enforcer.Refresh(pair.RefreshToken); err != nil {
+			t.Errorf("%v Refresh() failed: %v", alg, err)
+		}
+
+		if err = enforcer.RevokeToken(token); err != nil {
+			t.Fatalf("%v RevokeToken() failed: %v", alg, err)
+		}
+		if _, err = enforcer.GetIdByToken(token); !errors.Is(err, ErrRevoked) {
+			t.Errorf("%v GetIdByToken() error = %v, want ErrRevoked", alg, err)
+		}
+
+		// error reports the JWE token rather than the nested token
+		clock.Add(61 * time.Second)
+		_, err = enforcer.GetIdByToken(pair.AccessToken)
+		var tokenErr *TokenError
+		if !errors.Is(err, ErrExpired) || !errors.As(err, &tokenErr) || tokenErr.Token != redactToken(pair.AccessToken) {
+			t.Errorf("%v GetIdByToken() error = %v, want ErrExpired of JWE token", alg, err)
+		}
+	}
+}
+
+func TestStatelessEnforcer_JWEReject(t *testing.T) {
+	keys := newTestEncryptionKeys(t)
+	enforcer := newTestEnforcer(t)
+	enforcer.SetSecretKey("123")
+
+	signed, err := enforcer.Login("1", nil)
+	if err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+	enforcer.SetEncryptionKey(keys["dir"])
+	if _, err = enforcer.GetIdByToken(signed); !errors.Is(err, ErrMalformed) {
+		t.Errorf("GetIdByToken() error = %v, want ErrMalformed for unencrypted token", err)
+	}
+
+	// token encrypted by another key
+	enforcer.SetEncryptionKey(keys["ECDH-ES"])
+	token, err := enforcer.Login("1", nil)
+	if err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+	enforcer.SetEncryptionKey(keys["dir"])
+	if _, err = enforcer.GetIdByToken(token); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("GetIdByToken() error = %v, want ErrInvalidSignature", err)
+	}
+	other, _ := NewDirectKey(make([]byte, 32))
+	enforcer.SetEncryptionKey(other)
+	token, _ = enforcer.Login("1", nil)
+	enforcer.SetEncryptionKey(keys["dir"])
+	if _, err = enforcer.GetIdByToken(token); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("GetIdByToken() error = %v, want ErrInvalidSignature", err)
+	}
+
+	// compressed token
+	encrypter, _ := jose.NewEncrypter(jose.A256GCM, jose.Recipient{Algorithm: jose.DIRECT, Key: keys["dir"].EncryptKey()},
+		(&jose.EncrypterOptions{Compression: jose.DEFLATE}).WithContentType("JWT"))
+	object, _ := encrypter.Encrypt([]byte(signed))
+	token, _ = object.CompactSerialize()
+	if _, err = enforcer.GetIdByToken(token); !errors.Is(err, ErrMalformed) {
+		t.Errorf("GetIdByToken() error = %v, want ErrMalformed for compressed token", err)
+	}
+
+	enforcer.SetEncryptionKey(nil)
+	if id, err := enforcer.GetIdByToken(signed); err != nil || id != "1" {
+		t.Errorf("GetIdByToken() failed after disable JWE: id = %v, err = %v", id, err)
+	}
+}
@@ -0,0 +1,248 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+)
+
+// JWKS_PATH well-known path of JWKS document
+const JWKS_PATH = "/.well-known/jwks.json"
+
+// JWK json web key, only contains public key fields, see RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA public key
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC | OKP public key
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS json web key set
+type JWKS struct {
+	Keys []*JWK `json:"keys"`
+}
+
+// NewJWK convert the verify key of SigningKey to JWK, HMAC key can not be published
+func NewJWK(kid string, key SigningKey) (*JWK, error) {
+	if key == nil {
+		return nil, fmt.Errorf("arg key can not be nil")
+	}
+	jwk := &JWK{
+		Kid: kid,
+		Use: "sig",
+		Alg: key.Method().Alg(),
+	}
+	switch k := key.VerifyKey().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encodeBase64(k.N.Bytes())
+		jwk.E = encodeBase64(big.NewInt(int64(k.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = k.Curve.Params().Name
+		jwk.X = encodeBase64(k.X.FillBytes(make([]byte, size)))
+		jwk.Y = encodeBase64(k.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = encodeBase64(k)
+	default:
+		return nil, fmt.Errorf("unsupported JWK key type: %T", k)
+	}
+	return jwk, nil
+}
+
+// Thumbprint JWK SHA-256 thumbprint, see RFC 7638
+func (j *JWK) Thumbprint() (string, error) {
+	var members map[string]string
+	switch j.Kty {
+	case "RSA":
+		members = map[string]string{"e": j.E, "kty": j.Kty, "n": j.N}
+	case "EC":
+		members = map[string]string{"crv": j.Crv, "kty": j.Kty, "x": j.X, "y": j.Y}
+	case "OKP":
+		members = map[string]string{"crv": j.Crv, "kty": j.Kty, "x": j.X}
+	default:
+		return "", fmt.Errorf("unsupported JWK key type: %v", j.Kty)
+	}
+	// map keys are sorted by encoding/json
+	b, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return encodeBase64(sum[:]), nil
+}
+
+// SigningKey convert JWK to a SigningKey which can only verify token
+func (j *JWK) SigningKey() (SigningKey, error) {
+	var key SigningKey
+	var err error
+	switch j.Kty {
+	case "RSA":
+		key, err = j.rsaKey()
+	case "EC":
+		key, err = j.ecdsaKey()
+	case "OKP":
+		key, err = j.ed25519Key()
+	default:
+		return nil, fmt.Errorf("unsupported JWK key type: %v", j.Kty)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if j.Alg != "" && j.Alg != key.Method().Alg() {
+		return nil, fmt.Errorf("unsupported JWK alg: %v", j.Alg)
+	}
+	return key, nil
+}
+
+func (j *JWK) rsaKey() (SigningKey, error) {
+	n, err := decodeBase64Int(j.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeBase64Int(j.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("invalid RSA JWK exponent: %v", j.E)
+	}
+	return NewRSAPublicKey(&rsa.PublicKey{N: n, E: int(e.Int64())})
+}
+
+func (j *JWK) ecdsaKey() (SigningKey, error) {
+	var curve elliptic.Curve
+	switch j.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported EC JWK curve: %v", j.Crv)
+	}
+	x, err := decodeBase64Int(j.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBase64Int(j.Y)
+	if err != nil {
+		return nil, err
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("invalid EC JWK: point is not on curve %v", j.Crv)
+	}
+	return NewECDSAPublicKey(&ecdsa.PublicKey{Curve: curve, X: x, Y: y})
+}
+
+func (j *JWK) ed25519Key() (SigningKey, error) {
+	if j.Crv != "Ed25519" {
+		return nil, fmt.Errorf("unsupported OKP JWK curve: %v", j.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(j.X)
+	if err != nil {
+		return nil, err
+	}
+	return NewEd25519PublicKey(x)
+}
+
+// GetJWKS get the public verification keys, HMAC keys are skipped.
+// If KeyRing is set, return all keys which are not retired
+func (s *StatelessEnforcer) GetJWKS() (*JWKS, error) {
+	jwks := &JWKS{Keys: make([]*JWK, 0)}
+	if s.keyRing == nil {
+		key := s.GetSigningKey()
+		if !isPublishable(key) {
+			return jwks, nil
+		}
+		jwk, err := NewJWK("", key)
+		if err != nil {
+			return nil, err
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+		return jwks, nil
+	}
+
+	for _, kid := range s.keyRing.Kids() {
+		key, ok := s.keyRing.GetKey(kid)
+		if !ok || !isPublishable(key) {
+			continue
+		}
+		jwk, err := NewJWK(kid, key)
+		if err != nil {
+			return nil, err
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks, nil
+}
+
+// NewJWKSHandler serve the JWKS document of enforcer, usually mounted on JWKS_PATH.
+// The document is built on every request, so it reflects key rotation automatically
+func NewJWKSHandler(enforcer *StatelessEnforcer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		jwks, err := enforcer.GetJWKS()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		body, err := json.Marshal(jwks)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(body)
+		}
+	})
+}
+
+// isPublishable symmetric key must not be published
+func isPublishable(key SigningKey) bool {
+	if key == nil {
+		return false
+	}
+	_, symmetric := key.VerifyKey().([]byte)
+	return !symmetric
+}
+
+func encodeBase64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeBase64Int(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("invalid JWK: empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
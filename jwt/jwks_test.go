@@ -0,0 +1,91 @@
+package jwt
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func getTestJWKS(t *testing.T, handler http.Handler) *JWKS {
+	req := httptest.NewRequest(http.MethodGet, JWKS_PATH, nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("JWKS handler failed: unexpected status %v", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("JWKS handler failed: unexpected Content-Type %v", ct)
+	}
+	jwks := &JWKS{}
+	if err := json.Unmarshal(rec.Body.Bytes(), jwks); err != nil {
+		t.Fatalf("json.Unmarshal() failed: %v", err)
+	}
+	return jwks
+}
+
+func TestJWKSHandler(t *testing.T) {
+	keys := newTestSigningKeys(t)
+	keyRing, err := NewKeyRing("rsa", keys["RS256"][0])
+	if err != nil {
+		t.Fatalf("NewKeyRing() failed: %v", err)
+	}
+	enforcer, err := NewEnforcerWithKeyRing(keyRing)
+	if err != nil {
+		t.Fatalf("NewEnforcerWithKeyRing() failed: %v", err)
+	}
+	handler := NewJWKSHandler(enforcer)
+
+	jwks := getTestJWKS(t, handler)
+	if len(jwks.Keys) != 1 {
+		t.Fatalf("unexpected keys length %v", len(jwks.Keys))
+	}
+	jwk := jwks.Keys[0]
+	if jwk.Kid != "rsa" || jwk.Kty != "RSA" || jwk.Alg != "RS256" || jwk.N == "" || jwk.E != "AQAB" {
+		t.Errorf("unexpected RSA JWK: %+v", jwk)
+	}
+
+	// rotation is reflected
+	_ = keyRing.Rotate("ec", keys["ES256"][0], -1)
+	_ = keyRing.AddKey("ed", keys["EdDSA"][1], -1)
+	_ = keyRing.AddKey("hmac", NewHMACKey("secret"), -1)
+	jwks = getTestJWKS(t, handler)
+	if len(jwks.Keys) != 3 {
+		t.Fatalf("unexpected keys length %v", len(jwks.Keys))
+	}
+	for _, k := range jwks.Keys {
+		switch k.Kid {
+		case "ec":
+			if k.Kty != "EC" || k.Crv != "P-256" || len(k.X) != 43 || len(k.Y) != 43 {
+				t.Errorf("unexpected EC JWK: %+v", k)
+			}
+		case "ed":
+			if k.Kty != "OKP" || k.Crv != "Ed25519" || k.Alg != "EdDSA" {
+				t.Errorf("unexpected OKP JWK: %+v", k)
+			}
+		case "rsa":
+		default:
+			t.Errorf("unexpected kid %v", k.Kid)
+		}
+	}
+
+	_ = keyRing.RemoveKey("rsa")
+	if jwks = getTestJWKS(t, handler); len(jwks.Keys) != 2 {
+		t.Errorf("unexpected keys length %v", len(jwks.Keys))
+	}
+}
+
+func TestJWKSHandler_HMAC(t *testing.T) {
+	enforcer := newTestEnforcer(t)
+	enforcer.SetSecretKey("123")
+	jwks := getTestJWKS(t, NewJWKSHandler(enforcer))
+	if len(jwks.Keys) != 0 {
+		t.Errorf("HMAC key should not be published: %+v", jwks.Keys)
+	}
+
+	rec := httptest.NewRecorder()
+	NewJWKSHandler(enforcer).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, JWKS_PATH, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("unexpected status %v", rec.Code)
+	}
+}
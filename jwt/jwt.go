@@ -2,8 +2,7 @@ package jwt
 
 import (
 	"errors"
-	"fmt"
-	"github.com/golang-jwt/jwt"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/weloe/token-go/constant"
 	"github.com/weloe/token-go/util"
 	"time"
@@ -22,20 +21,44 @@ const (
 	// EFF expirationTime
 	EFF    = "eff"
 	RANDOM = "random"
+	// JTI unique id of token, same as RANDOM
+	JTI = "jti"
 	// NEVER_EXPIRE if expiration time <= -1, return NEVER_EXPIRE
 	NEVER_EXPIRE = constant.NeverExpire
 	// NOT_VALUE_EXPIRE if expiration time < time.now(), return NOT_VALUE_EXPIRE
 	NOT_VALUE_EXPIRE = constant.NotValueExpire
 	// EXTRA_DATA extra data key
 	EXTRA_DATA = "extraData"
+	// TOKEN_TYPE token type key, access token does not have this key
+	TOKEN_TYPE = "tokenType"
 )
 
-// createToken create JWT token and set data
+// createToken create HS256 JWT token and set data
 func createToken(loginType string, loginId string, device string, timeout int64, extraData map[string]interface{}, secretKey string) (string, error) {
+	return createTokenByKey(loginType, loginId, device, timeout, extraData, &staticKeySet{NewHMACKey(secretKey)})
+}
+
+// createTokenByKey create JWT token and set data, sign with the signing key of keySet
+func createTokenByKey(loginType string, loginId string, device string, timeout int64, extraData map[string]interface{}, keys keySet) (string, error) {
+	claims, err := newClaims(loginType, loginId, device, timeout, extraData, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := generateToken(claims, keys)
+	if err != nil {
+		return "", err
+	}
+
+	return signature, nil
+}
+
+// newClaims create JWT payload, expiration time is calculated from now
+func newClaims(loginType string, loginId string, device string, timeout int64, extraData map[string]interface{}, now time.Time) (jwt.MapClaims, error) {
 	// set expiration time
 	var expirationTime int64
 	if timeout > NEVER_EXPIRE {
-		expirationTime = time.Now().UnixMilli() + timeout*1000
+		expirationTime = now.UnixMilli() + timeout*1000
 	} else {
 		expirationTime = timeout
 	}
@@ -43,7 +66,7 @@ func createToken(loginType string, loginId string, device string, timeout int64,
 	var claims jwt.MapClaims
 	randomString32, err := util.GenerateRandomString32()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	// set claims
 	if extraData != nil {
@@ -53,6 +76,7 @@ func createToken(loginType string, loginId string, device string, timeout int64,
 			DEVICE:     device,
 			EFF:        expirationTime,
 			RANDOM:     randomString32,
+			JTI:        randomString32,
 			EXTRA_DATA: extraData,
 		}
 	} else {
@@ -62,21 +86,28 @@ func createToken(loginType string, loginId string, device string, timeout int64,
 			DEVICE:     device,
 			EFF:        expirationTime,
 			RANDOM:     randomString32,
+			JTI:        randomString32,
 		}
 	}
 
-	signature, err := generateToken(claims, secretKey)
+	return claims, nil
+}
+
+func generateToken(claims jwt.MapClaims, keys keySet) (string, error) {
+	kid, key, err := keys.signingKey()
 	if err != nil {
 		return "", err
 	}
+	if key == nil || key.SignKey() == nil {
+		return "", errors.New("the signing key can not sign token")
+	}
 
-	return signature, nil
-}
-
-func generateToken(claims jwt.MapClaims, secretKey string) (string, error) {
-
-	// sign and get the complete signed token as a string using the secret
-	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secretKey))
+	jwtToken := jwt.NewWithClaims(key.Method(), claims)
+	if kid != "" {
+		jwtToken.Header[KID] = kid
+	}
+	// sign and get the complete signed token as a string using the signing key
+	token, err := jwtToken.SignedString(key.SignKey())
 
 	if err != nil {
 		return "", err
@@ -85,113 +116,171 @@ func generateToken(claims jwt.MapClaims, secretKey string) (string, error) {
 	return token, nil
 }
 
-// parseToken parse token, return JWT payload
+// parseToken parse HS256 token, return JWT payload
 func parseToken(token string, loginType string, secretKey string, isCheckTimeout bool) (jwt.MapClaims, error) {
+	return parseTokenByKey(token, loginType, &staticKeySet{NewHMACKey(secretKey)}, isCheckTimeout, defaultValidator)
+}
+
+// parseTokenByKey parse access token and verify it with the key selected by kid, return JWT payload
+func parseTokenByKey(token string, loginType string, keys keySet, isCheckTimeout bool, v *validator) (jwt.MapClaims, error) {
+	payloads, err := verifyToken(token, loginType, keys, isCheckTimeout, v)
+	if err != nil {
+		return nil, err
+	}
 
-	// secretKey cannot be empty
-	if secretKey == "" {
-		return nil, errors.New("please configure the JWT secret key")
+	// refresh token can not be used as access token
+	if payloads[TOKEN_TYPE] != nil {
+		return nil, newTokenError(ErrTokenTypeMismatch, token, nil)
 	}
 
+	return payloads, nil
+}
+
+// verifyToken parse token, verify signature, login type and claims, return JWT payload.
+// If isCheckTimeout is false, expiration time is not verified
+func verifyToken(token string, loginType string, keys keySet, isCheckTimeout bool, v *validator) (jwt.MapClaims, error) {
+
 	// if token is null
 	if token == "" {
-		return nil, errors.New("JWT string cannot be null")
+		return nil, newTokenError(ErrMalformed, token, errors.New("JWT string cannot be null"))
 	}
 
-	// parse
-	jwtToken, err := jwt.Parse(token, func(jwtToken *jwt.Token) (interface{}, error) {
+	// parse, claims are verified by validator
+	jwtToken, err := v.newParser().Parse(token, func(jwtToken *jwt.Token) (interface{}, error) {
+		// select key by kid
+		kid, _ := jwtToken.Header[KID].(string)
+		key, err := keys.verifyingKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		// key cannot be empty
+		if key == nil || isEmptyKey(key.VerifyKey()) {
+			return nil, errors.New("please configure the JWT secret key")
+		}
 		// verify sign alg
-		if jwtToken.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+		if jwtToken.Method.Alg() != key.Method().Alg() {
 			return nil, errors.New("Invalid signing algorithm: " + jwtToken.Method.Alg())
 		}
-		// return secretKey
-		return []byte(secretKey), nil
+		// return verify key
+		return key.VerifyKey(), nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("JWT parsing failed: %v", err)
+		return nil, classifyError(token, err)
 	}
 	payloads, ok := jwtToken.Claims.(jwt.MapClaims)
 
 	// verify token signature
-	verifyErr := jwtToken.Claims.Valid()
-	if verifyErr != nil {
-		return nil, errors.New("Invalid JWT signature: " + token)
+	if !jwtToken.Valid {
+		return nil, newTokenError(ErrInvalidSignature, token, jwt.ErrTokenSignatureInvalid)
 	}
 
 	// verify login type
 	if !ok || payloads[LOGIN_TYPE] != loginType {
-		return nil, errors.New("Invalid JWT login type: " + token)
+		return nil, newTokenError(ErrLoginTypeMismatch, token, nil)
 	}
 
-	// verify Token expiration time
-	if isCheckTimeout {
-		effFloat, ok := payloads[EFF].(float64)
-
-		if !ok || effFloat < float64(time.Now().UnixMilli()) {
-			return nil, errors.New("JWT has expired: " + token)
-		}
+	// verify claims
+	err = v.validate(token, payloads, isCheckTimeout)
+	if err != nil {
+		return nil, classifyError(token, err)
 	}
 
 	return payloads, nil
 }
 
+func isEmptyKey(key interface{}) bool {
+	if key == nil {
+		return true
+	}
+	if b, ok := key.([]byte); ok {
+		return len(b) == 0
+	}
+	return false
+}
+
 func getId(token string, loginType string, secretKey string) (string, error) {
-	payloads, err := parseToken(token, loginType, secretKey, true)
+	return getIdByKey(token, loginType, &staticKeySet{NewHMACKey(secretKey)}, defaultValidator)
+}
+
+func getIdByKey(token string, loginType string, keys keySet, v *validator) (string, error) {
+	payloads, err := parseTokenByKey(token, loginType, keys, true, v)
 	if err != nil {
 		return "", err
 	}
-	id, ok := payloads[LOGIN_ID].(string)
+	return getIdFromClaims(token, payloads)
+}
+
+// getIdFromClaims get loginId from verified JWT payload
+func getIdFromClaims(token string, payloads jwt.MapClaims) (string, error) {
+	id, ok := claimLoginId(payloads)
 	if !ok {
-		return "", errors.New("Invalid JWT loginId: " + token)
+		return "", newTokenError(ErrInvalidClaims, token, errors.New("invalid loginId"))
 	}
 	return id, nil
 }
 
-// getTimeout parse and verify loginType return timeout
-func getTimeout(token string, loginType string, secretKey string) (int64, error) {
-	// parse
-	jwtToken, err := jwt.Parse(token, func(jwtToken *jwt.Token) (interface{}, error) {
-		// verify sign alg
-		if jwtToken.Method.Alg() != jwt.SigningMethodHS256.Alg() {
-			return nil, errors.New("Invalid signing algorithm: " + jwtToken.Method.Alg())
-		}
-		// return secretKey
-		return []byte(secretKey), nil
-	})
+// getExtraDataByKey parse token and get the value of extraData map by key
+func getExtraDataByKey(token string, loginType string, keys keySet, key string, v *validator) (interface{}, error) {
+	mapClaims, err := parseTokenByKey(token, loginType, keys, true, v)
 	if err != nil {
-		return NOT_VALUE_EXPIRE, errors.New("JWT parsing failed: " + token)
+		return nil, err
 	}
-	payloads, ok := jwtToken.Claims.(jwt.MapClaims)
+	return getExtraDataFromClaims(mapClaims, key)
+}
 
-	// verify token signature
-	verifyErr := jwtToken.Claims.Valid()
-	if verifyErr != nil {
-		return NOT_VALUE_EXPIRE, errors.New("Invalid JWT signature: " + token)
+// getExtraDataFromClaims get the value of extraData map by key from verified JWT payload
+func getExtraDataFromClaims(mapClaims jwt.MapClaims, key string) (interface{}, error) {
+	extraData := mapClaims[EXTRA_DATA]
+	if extraData == nil {
+		return nil, nil
+	}
+	extraMap, ok := extraData.(map[string]interface{})
+	if !ok {
+		return nil, newTokenError(ErrInvalidClaims, "", errors.New("invalid extraData"))
 	}
+	return extraMap[key], nil
+}
 
-	// verify login type
-	if !ok || payloads[LOGIN_TYPE] != loginType {
-		return NOT_VALUE_EXPIRE, errors.New("Invalid JWT login type: " + token)
+// getJti get the unique id of token, token created before jti claim use random claim
+func getJti(payloads jwt.MapClaims) string {
+	if jti, ok := payloads[JTI].(string); ok && jti != "" {
+		return jti
+	}
+	random, _ := payloads[RANDOM].(string)
+	return random
+}
+
+// getTimeout parse and verify loginType return timeout
+func getTimeout(token string, loginType string, secretKey string) (int64, error) {
+	return getTimeoutByKey(token, loginType, &staticKeySet{NewHMACKey(secretKey)}, defaultValidator)
+}
+
+// getTimeoutByKey parse and verify loginType with keySet return timeout
+func getTimeoutByKey(token string, loginType string, keys keySet, v *validator) (int64, error) {
+	payloads, err := parseTokenByKey(token, loginType, keys, false, v)
+	if err != nil {
+		return NOT_VALUE_EXPIRE, err
 	}
 
-	return calTimeout(token, payloads)
+	return calTimeout(token, payloads, v.now())
 }
 
-func calTimeout(token string, payloads jwt.MapClaims) (int64, error) {
+// calTimeout calculate the remaining seconds from now
+func calTimeout(token string, payloads jwt.MapClaims, now time.Time) (int64, error) {
 	// Convert inputValue to int64
-	intValue, ok := payloads[EFF].(float64)
+	intValue, ok := claimExpiration(payloads)
 	if !ok {
-		return 0, errors.New("Invalid JWT expiration time: " + token)
+		return 0, newTokenError(ErrInvalidClaims, token, errors.New("invalid expiration time"))
 	}
 
-	if intValue <= float64(NEVER_EXPIRE) {
+	if intValue <= NEVER_EXPIRE {
 		return NEVER_EXPIRE, nil
 	}
 
-	if intValue < float64(time.Now().UnixMilli()) {
+	if intValue < now.UnixMilli() {
 		return NOT_VALUE_EXPIRE, nil
 	}
 
-	f := intValue - float64(time.Now().UnixMilli())
-	return int64(f / 1000), nil
+	f := intValue - now.UnixMilli()
+	return f / 1000, nil
 }
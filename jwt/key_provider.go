@@ -0,0 +1,256 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var _ KeyProvider = (*EnvKeyProvider)(nil)
+var _ KeyProvider = (*FileKeyProvider)(nil)
+var _ KeyProvider = (*CompositeKeyProvider)(nil)
+var _ keySet = (*providerKeySet)(nil)
+
+// KeyProvider provide the current key to sign and verify token, it is called on every sign and verify,
+// so the key can rotate without restart
+type KeyProvider interface {
+	GetKey() (SigningKey, error)
+}
+
+// providerKeySet use the current key of KeyProvider to sign and verify, ignore kid
+type providerKeySet struct {
+	provider KeyProvider
+}
+
+func (p *providerKeySet) signingKey() (string, SigningKey, error) {
+	key, err := p.provider.GetKey()
+	return "", key, err
+}
+
+func (p *providerKeySet) verifyingKey(kid string) (SigningKey, error) {
+	return p.provider.GetKey()
+}
+
+// EnvKeyProvider read key from environment variable on every call,
+// the parsed key is cached and parsed again only if the value is changed
+type EnvKeyProvider struct {
+	name  string
+	mu    sync.RWMutex
+	key   SigningKey
+	value string
+}
+
+// NewEnvKeyProvider the value of environment variable is HS256 secret or PEM encoded private key
+func NewEnvKeyProvider(name string) *EnvKeyProvider {
+	return &EnvKeyProvider{name: name}
+}
+
+func (p *EnvKeyProvider) GetKey() (SigningKey, error) {
+	value := os.Getenv(p.name)
+	if value == "" {
+		return nil, fmt.Errorf("environment variable %v is not set", p.name)
+	}
+	p.mu.RLock()
+	key, changed := p.key, value != p.value
+	p.mu.RUnlock()
+	if !changed {
+		return key, nil
+	}
+	key, err := parseSigningKey([]byte(value))
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.key = key
+	p.value = value
+	p.mu.Unlock()
+	return key, nil
+}
+
+// FileKeyProvider read key from file, the file is watched and reloaded when it changes.
+// If the changed file can not be parsed, the previous key is kept
+type FileKeyProvider struct {
+	path    string
+	mu      sync.RWMutex
+	key     SigningKey
+	content []byte
+	watcher *fsnotify.Watcher
+}
+
+// NewFileKeyProvider the content of file is HS256 secret or PEM encoded private key.
+// Call Close to stop watching
+func NewFileKeyProvider(path string) (*FileKeyProvider, error) {
+	if path == "" {
+		return nil, errors.New("arg path can not be nil")
+	}
+	p := &FileKeyProvider{path: filepath.Clean(path)}
+	err := p.load()
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// watch the directory, file replaced by rename or symlink, such as kubernetes secret, is also reloaded
+	err = watcher.Add(filepath.Dir(p.path))
+	if err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+	p.watcher = watcher
+	go p.watch()
+	return p, nil
+}
+
+func (p *FileKeyProvider) GetKey() (SigningKey, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.key, nil
+}
+
+// Close stop watching the file
+func (p *FileKeyProvider) Close() error {
+	return p.watcher.Close()
+}
+
+func (p *FileKeyProvider) watch() {
+	for {
+		select {
+		case _, ok := <-p.watcher.Events:
+			if !ok {
+				return
+			}
+			// any change of directory may replace the file, reload is skipped if content is not changed
+			_ = p.load()
+		case _, ok := <-p.watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// load read and parse file, key is replaced only if content is changed and valid
+func (p *FileKeyProvider) load() error {
+	content, err := os.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+	p.mu.RLock()
+	changed := !bytes.Equal(content, p.content)
+	p.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+	key, err := parseSigningKey(content)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.key = key
+	p.content = content
+	return nil
+}
+
+// CompositeKeyProvider return the key of the first provider which succeeds
+type CompositeKeyProvider struct {
+	providers []KeyProvider
+}
+
+// NewCompositeKeyProvider fallback to the next provider if the previous provider fails,
+// such as environment variable first and then file
+func NewCompositeKeyProvider(providers ...KeyProvider) (*CompositeKeyProvider, error) {
+	if len(providers) == 0 {
+		return nil, errors.New("arg providers can not be nil")
+	}
+	for _, provider := range providers {
+		if provider == nil {
+			return nil, errors.New("provider can not be nil")
+		}
+	}
+	return &CompositeKeyProvider{providers: providers}, nil
+}
+
+func (p *CompositeKeyProvider) GetKey() (SigningKey, error) {
+	var errs []string
+	for _, provider := range p.providers {
+		key, err := provider.GetKey()
+		if err == nil && key != nil {
+			return key, nil
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	return nil, fmt.Errorf("no key is provided: [%v]", strings.Join(errs, "; "))
+}
+
+// parseSigningKey parse PEM encoded PKCS #8, PKCS #1 or EC private key, otherwise use content as HS256 secret
+func parseSigningKey(content []byte) (SigningKey, error) {
+	content = bytes.TrimSpace(content)
+	if len(content) == 0 {
+		return nil, errors.New("key can not be empty")
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return NewHMACKey(string(content)), nil
+	}
+
+	if privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		switch k := privateKey.(type) {
+		case *rsa.PrivateKey:
+			return NewRSAKey(k)
+		case *ecdsa.PrivateKey:
+			return NewECDSAKey(k)
+		case ed25519.PrivateKey:
+			return NewEd25519Key(k)
+		}
+		return nil, fmt.Errorf("unsupported private key type %T", privateKey)
+	}
+	if privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return NewRSAKey(privateKey)
+	}
+	if privateKey, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return NewECDSAKey(privateKey)
+	}
+	return nil, fmt.Errorf("unsupported PEM block %v", block.Type)
+}
+
+// SetKeyProvider set the KeyProvider used to sign and verify token, the current key is pulled from provider
+// on every sign and verify. KeyRing takes precedence over KeyProvider, KeyProvider takes precedence over SigningKey
+func (s *StatelessEnforcer) SetKeyProvider(provider KeyProvider) {
+	s.keyProvider = provider
+	s.tokenCache.clear()
+}
+
+// GetKeyProvider get the KeyProvider, return nil if not set
+func (s *StatelessEnforcer) GetKeyProvider() KeyProvider {
+	return s.keyProvider
+}
+
+// NewEnforcerWithKeyProvider new jwt enforcer which sign and verify token with the current key of KeyProvider,
+// other parameter need TokenConfig or string
+func NewEnforcerWithKeyProvider(provider KeyProvider, args ...interface{}) (*StatelessEnforcer, error) {
+	if provider == nil {
+		return nil, errors.New("arg provider can not be nil")
+	}
+	s, err := NewEnforcer(args...)
+	if err != nil {
+		return nil, err
+	}
+	s.SetKeyProvider(provider)
+	return s, nil
+}
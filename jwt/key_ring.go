@@ -0,0 +1,196 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// KID jwt header key of key id
+const KID = "kid"
+
+var _ keySet = (*KeyRing)(nil)
+var _ keySet = (*staticKeySet)(nil)
+
+// keySet provide the key to sign token and find the key to verify token
+type keySet interface {
+	// signingKey return the key id and the key used to sign token, kid can be empty
+	signingKey() (string, SigningKey, error)
+	// verifyingKey return the key used to verify token by key id
+	verifyingKey(kid string) (SigningKey, error)
+}
+
+// staticKeySet use one key to sign and verify, ignore kid
+type staticKeySet struct {
+	key SigningKey
+}
+
+func (s *staticKeySet) signingKey() (string, SigningKey, error) {
+	return "", s.key, nil
+}
+
+func (s *staticKeySet) verifyingKey(kid string) (SigningKey, error) {
+	return s.key, nil
+}
+
+type ringKey struct {
+	key SigningKey
+	// retireTime unix milli, if retireTime <= NEVER_EXPIRE, the key does not retire
+	retireTime int64
+}
+
+func (k *ringKey) isRetired(now int64) bool {
+	return k.retireTime > NEVER_EXPIRE && k.retireTime <= now
+}
+
+// KeyRing hold one active signing key and old keys still accepted for verification until they retire.
+// Token signed by KeyRing has a kid header, it is safe to rotate key while serving traffic.
+type KeyRing struct {
+	mu       sync.RWMutex
+	activeId string
+	keys     map[string]*ringKey
+}
+
+// NewKeyRing new key ring with an active signing key
+func NewKeyRing(kid string, key SigningKey) (*KeyRing, error) {
+	r := &KeyRing{keys: make(map[string]*ringKey)}
+	err := r.Rotate(kid, key, NEVER_EXPIRE)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Rotate set a new active signing key, the previous active key is still accepted for verification,
+// and retire after retireTimeout seconds. If retireTimeout <= NEVER_EXPIRE, the previous key does not retire
+func (r *KeyRing) Rotate(kid string, key SigningKey, retireTimeout int64) error {
+	if kid == "" {
+		return errors.New("arg kid can not be nil")
+	}
+	if key == nil || key.SignKey() == nil {
+		return errors.New("the active key must be able to sign token")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UnixMilli()
+	r.prune(now)
+	if previous, ok := r.keys[r.activeId]; ok && r.activeId != kid {
+		previous.retireTime = retireTime(now, retireTimeout)
+	}
+	r.keys[kid] = &ringKey{key: key, retireTime: NEVER_EXPIRE}
+	r.activeId = kid
+	return nil
+}
+
+// AddKey add a verification key, the key retire after timeout seconds.
+// If timeout <= NEVER_EXPIRE, the key does not retire
+func (r *KeyRing) AddKey(kid string, key SigningKey, timeout int64) error {
+	if kid == "" {
+		return errors.New("arg kid can not be nil")
+	}
+	return r.addKey(kid, key, timeout)
+}
+
+// AddLegacyKey add a verification key for token without kid, such as token issued before the key ring is enabled,
+// the key retire after timeout seconds. If timeout <= NEVER_EXPIRE, the key does not retire.
+// Legacy key is not published by JWKS, call RemoveKey("") to remove it
+func (r *KeyRing) AddLegacyKey(key SigningKey, timeout int64) error {
+	return r.addKey("", key, timeout)
+}
+
+func (r *KeyRing) addKey(kid string, key SigningKey, timeout int64) error {
+	if key == nil {
+		return errors.New("arg key can not be nil")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if kid == r.activeId {
+		return fmt.Errorf("kid = %v is the active key, use Rotate() instead", kid)
+	}
+	now := time.Now().UnixMilli()
+	r.prune(now)
+	r.keys[kid] = &ringKey{key: key, retireTime: retireTime(now, timeout)}
+	return nil
+}
+
+// RemoveKey retire a verification key immediately, the active key can not be removed
+func (r *KeyRing) RemoveKey(kid string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if kid == r.activeId {
+		return fmt.Errorf("kid = %v is the active key, can not be removed", kid)
+	}
+	delete(r.keys, kid)
+	return nil
+}
+
+// ActiveKey return the active key id and signing key
+func (r *KeyRing) ActiveKey() (string, SigningKey) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.activeId, r.keys[r.activeId].key
+}
+
+// GetKey return the key which is not retired by kid
+func (r *KeyRing) GetKey(kid string) (SigningKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	k, ok := r.keys[kid]
+	if !ok || k.isRetired(time.Now().UnixMilli()) {
+		return nil, false
+	}
+	return k.key, true
+}
+
+// Kids return all key ids which are not retired, sorted. Legacy key is excluded
+func (r *KeyRing) Kids() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	now := time.Now().UnixMilli()
+	kids := make([]string, 0, len(r.keys))
+	for kid, k := range r.keys {
+		if kid != "" && !k.isRetired(now) {
+			kids = append(kids, kid)
+		}
+	}
+	sort.Strings(kids)
+	return kids
+}
+
+func (r *KeyRing) signingKey() (string, SigningKey, error) {
+	kid, key := r.ActiveKey()
+	return kid, key, nil
+}
+
+func (r *KeyRing) verifyingKey(kid string) (SigningKey, error) {
+	key, ok := r.GetKey(kid)
+	if !ok && kid == "" {
+		// token without kid is accepted only by legacy key
+		return nil, errors.New("JWT header kid cannot be null")
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown JWT kid: %v", kid)
+	}
+	return key, nil
+}
+
+// prune delete retired keys, must hold the lock
+func (r *KeyRing) prune(now int64) {
+	for kid, k := range r.keys {
+		if kid != r.activeId && k.isRetired(now) {
+			delete(r.keys, kid)
+		}
+	}
+}
+
+func retireTime(now int64, timeout int64) int64 {
+	if timeout <= NEVER_EXPIRE {
+		return NEVER_EXPIRE
+	}
+	return now + timeout*1000
+}
@@ -0,0 +1,162 @@
+package jwt
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestKeyRingEnforcer(t *testing.T) (*StatelessEnforcer, *KeyRing) {
+	keyRing, err := NewKeyRing("k1", NewHMACKey("secret-1"))
+	if err != nil {
+		t.Fatalf("NewKeyRing() failed: %v", err)
+	}
+	enforcer, err := NewEnforcerWithKeyRing(keyRing)
+	if err != nil {
+		t.Fatalf("NewEnforcerWithKeyRing() failed: %v", err)
+	}
+	return enforcer, keyRing
+}
+
+func TestKeyRing_Rotate(t *testing.T) {
+	enforcer, keyRing := newTestKeyRingEnforcer(t)
+
+	oldToken, err := enforcer.Login("1", nil)
+	if err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+
+	err = keyRing.Rotate("k2", NewHMACKey("secret-2"), 60)
+	if err != nil {
+		t.Fatalf("Rotate() failed: %v", err)
+	}
+	if kid, _ := keyRing.ActiveKey(); kid != "k2" {
+		t.Errorf("ActiveKey() failed: unexpected kid %v", kid)
+	}
+
+	newToken, err := enforcer.Login("2", nil)
+	if err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+
+	// old token is still accepted before retire
+	if id, err := enforcer.GetIdByToken(oldToken); err != nil || id != "1" {
+		t.Errorf("GetIdByToken() old token failed: id = %v, err = %v", id, err)
+	}
+	if id, err := enforcer.GetIdByToken(newToken); err != nil || id != "2" {
+		t.Errorf("GetIdByToken() new token failed: id = %v, err = %v", id, err)
+	}
+
+	err = keyRing.RemoveKey("k1")
+	if err != nil {
+		t.Fatalf("RemoveKey() failed: %v", err)
+	}
+	if _, err = enforcer.GetIdByToken(oldToken); err == nil {
+		t.Errorf("GetIdByToken() should reject token signed by removed key")
+	}
+	if err = keyRing.RemoveKey("k2"); err == nil {
+		t.Errorf("RemoveKey() should not remove the active key")
+	}
+}
+
+func TestKeyRing_Retire(t *testing.T) {
+	enforcer, keyRing := newTestKeyRingEnforcer(t)
+
+	oldToken, err := enforcer.Login("1", nil)
+	if err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+	// retire immediately
+	err = keyRing.Rotate("k2", NewHMACKey("secret-2"), 0)
+	if err != nil {
+		t.Fatalf("Rotate() failed: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if _, err = enforcer.GetIdByToken(oldToken); err == nil {
+		t.Errorf("GetIdByToken() should reject token signed by retired key")
+	}
+	if kids := keyRing.Kids(); len(kids) != 1 || kids[0] != "k2" {
+		t.Errorf("Kids() failed: unexpected kids %v", kids)
+	}
+}
+
+func TestKeyRing_RequireKid(t *testing.T) {
+	enforcer, _ := newTestKeyRingEnforcer(t)
+
+	token, err := createToken("user", "1", "device", 60, nil, "secret-1")
+	if err != nil {
+		t.Fatalf("createToken() failed: %v", err)
+	}
+	if _, err = enforcer.GetIdByToken(token); err == nil {
+		t.Errorf("GetIdByToken() should reject token without kid")
+	}
+}
+
+func TestKeyRing_MigrateLegacyToken(t *testing.T) {
+	// token issued by HS256 secret before the key ring is enabled
+	legacy, err := createToken("user", "1", "device", 60, nil, "secret-0")
+	if err != nil {
+		t.Fatalf("createToken() failed: %v", err)
+	}
+	forged, err := createToken("user", "1", "device", 60, nil, "secret-x")
+	if err != nil {
+		t.Fatalf("createToken() failed: %v", err)
+	}
+
+	enforcer, keyRing := newTestKeyRingEnforcer(t)
+	err = keyRing.AddLegacyKey(NewHMACKey("secret-0"), 60)
+	if err != nil {
+		t.Fatalf("AddLegacyKey() failed: %v", err)
+	}
+	if id, err := enforcer.GetIdByToken(legacy); err != nil || id != "1" {
+		t.Errorf("GetIdByToken() legacy token failed: id = %v, err = %v", id, err)
+	}
+	if _, err = enforcer.GetIdByToken(forged); err == nil {
+		t.Errorf("GetIdByToken() should reject token without kid signed by other key")
+	}
+	// new token is signed by the active key
+	token, err := enforcer.Login("2", nil)
+	if err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+	if id, err := enforcer.GetIdByToken(token); err != nil || id != "2" {
+		t.Errorf("GetIdByToken() failed: id = %v, err = %v", id, err)
+	}
+	// legacy key is not published
+	if kids := keyRing.Kids(); len(kids) != 1 || kids[0] != "k1" {
+		t.Errorf("Kids() = %v, want [k1]", kids)
+	}
+
+	err = keyRing.RemoveKey("")
+	if err != nil {
+		t.Fatalf("RemoveKey() failed: %v", err)
+	}
+	if _, err = enforcer.GetIdByToken(legacy); err == nil {
+		t.Errorf("GetIdByToken() should reject legacy token after legacy key is removed")
+	}
+}
+
+func TestKeyRing_ConcurrentRotate(t *testing.T) {
+	enforcer, keyRing := newTestKeyRingEnforcer(t)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			_ = keyRing.Rotate("k"+string(rune('a'+i)), NewHMACKey("secret"), -1)
+		}(i)
+		go func() {
+			defer wg.Done()
+			token, err := enforcer.Login("1", nil)
+			if err != nil {
+				t.Errorf("Login() failed: %v", err)
+				return
+			}
+			if _, err = enforcer.GetIdByToken(token); err != nil {
+				t.Errorf("GetIdByToken() failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
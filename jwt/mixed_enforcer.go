@@ -0,0 +1,136 @@
+package jwt
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	tokenGo "github.com/weloe/token-go"
+	"github.com/weloe/token-go/ctx"
+	"github.com/weloe/token-go/model"
+	"github.com/weloe/token-go/persist"
+	"time"
+)
+
+var _ IEnforcer = (*MixedEnforcer)(nil)
+
+// MixedEnforcer token is JWT and also registered in token-go session.
+// LoginId and extra data can be read from token, Logout, Kickout, Replaced and session data work like token-go.
+// Token is verified by both signature and server side state
+type MixedEnforcer struct {
+	*StatelessEnforcer
+}
+
+// NewMixedEnforcer new mixed enforcer with adapter, other parameter need TokenConfig or string
+func NewMixedEnforcer(adapter persist.Adapter, args ...interface{}) (*MixedEnforcer, error) {
+	if adapter == nil {
+		return nil, errors.New("arg adapter can not be nil")
+	}
+	if _, ok := adapter.(*persist.EmptyAdapter); ok {
+		return nil, errors.New("MixedEnforcer can not use persist.EmptyAdapter")
+	}
+	e, err := tokenGo.NewEnforcer(adapter, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &MixedEnforcer{
+		StatelessEnforcer: &StatelessEnforcer{
+			e:            e,
+			validator:    &validator{clock: time.Now},
+			versionCache: newVersionCache(defaultVersionCacheTimeout),
+			stateful:     true,
+		},
+	}, nil
+}
+
+// GetEnforcer get the token-go enforcer which stores session
+func (m *MixedEnforcer) GetEnforcer() *tokenGo.Enforcer {
+	return m.e
+}
+
+// Logout delete the session of request token and delete cookie
+func (m *MixedEnforcer) Logout(ctx ctx.Context) error {
+	err := m.checkRequestProof(ctx)
+	if err != nil {
+		return err
+	}
+	return m.e.Logout(ctx)
+}
+
+// LogoutByToken delete the session of token
+func (m *MixedEnforcer) LogoutByToken(token string) error {
+	return m.e.LogoutByToken(token)
+}
+
+// LogoutById delete all tokens of loginId
+func (m *MixedEnforcer) LogoutById(id string) error {
+	return m.e.LogoutById(id)
+}
+
+// Kickout kick out the tokens of loginId and device, parsing them returns errors.BeKicked
+func (m *MixedEnforcer) Kickout(id string, device string) error {
+	return m.e.Kickout(id, device)
+}
+
+// Replaced replace the tokens of loginId and device, parsing them returns errors.BeReplaced
+func (m *MixedEnforcer) Replaced(id string, device string) error {
+	return m.e.Replaced(id, device)
+}
+
+// IsLogin check if the request token is valid
+func (m *MixedEnforcer) IsLogin(ctx ctx.Context) (bool, error) {
+	token := m.GetRequestToken(ctx)
+	if token == "" {
+		return false, nil
+	}
+	_, err := m.parseRequestToken(ctx, token)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// IsLoginByToken check if the token is valid
+func (m *MixedEnforcer) IsLoginByToken(token string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+	_, err := m.GetIdByToken(token)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// IsLoginById check if one of the tokens of loginId is valid
+func (m *MixedEnforcer) IsLoginById(id string) (bool, error) {
+	return m.e.IsLoginById(id)
+}
+
+// GetSession get the session of loginId
+func (m *MixedEnforcer) GetSession(id string) *model.Session {
+	return m.e.GetSession(id)
+}
+
+// GetLoginCount get the count of tokens of loginId
+func (m *MixedEnforcer) GetLoginCount(id string) int {
+	return m.e.GetLoginCount(id)
+}
+
+// loginSession register token in token-go session and respond it to ctx.Context
+func (s *StatelessEnforcer) loginSession(id string, token string, loginModel *model.Login, ctx ctx.Context) (string, error) {
+	m := *loginModel
+	m.Token = token
+	return s.e.LoginByModel(id, &m, ctx)
+}
+
+// checkSession check the token is registered, and is not kicked, replaced or banned
+func (s *StatelessEnforcer) checkSession(token string, payloads jwt.MapClaims) error {
+	id, err := s.e.GetLoginIdByToken(token)
+	if err != nil {
+		return err
+	}
+	claimId, ok := claimLoginId(payloads)
+	if !ok || claimId != id {
+		return newTokenError(ErrInvalidClaims, token, errors.New("loginId does not match session"))
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package jwt
+
+import (
+	"errors"
+	"github.com/weloe/token-go/config"
+	tokenErrors "github.com/weloe/token-go/errors"
+	"github.com/weloe/token-go/model"
+	"github.com/weloe/token-go/persist"
+	"testing"
+)
+
+func newTestMixedEnforcer(t *testing.T, args ...interface{}) *MixedEnforcer {
+	enforcer, err := NewMixedEnforcer(persist.NewDefaultAdapter(), args...)
+	if err != nil {
+		t.Fatalf("NewMixedEnforcer() failed: %v", err)
+	}
+	enforcer.SetSecretKey("123")
+	return enforcer
+}
+
+func TestNewMixedEnforcer(t *testing.T) {
+	if _, err := NewMixedEnforcer(nil); err == nil {
+		t.Errorf("NewMixedEnforcer() should reject nil adapter")
+	}
+	if _, err := NewMixedEnforcer(&persist.EmptyAdapter{}); err == nil {
+		t.Errorf("NewMixedEnforcer() should reject persist.EmptyAdapter")
+	}
+}
+
+func TestMixedEnforcer_Login(t *testing.T) {
+	enforcer := newTestMixedEnforcer(t)
+
+	token, err := enforcer.LoginByModel("1", &model.Login{Device: "pc", Timeout: 60, JwtData: map[string]interface{}{"name": "weloe"}}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	// loginId can be read from token without session
+	if id, err := getId(token, "user", "123"); err != nil || id != "1" {
+		t.Errorf("getId() failed: id = %v, err = %v", id, err)
+	}
+	if id, err := enforcer.GetIdByToken(token); err != nil || id != "1" {
+		t.Errorf("GetIdByToken() failed: id = %v, err = %v", id, err)
+	}
+	if data, err := enforcer.GetExtraDataByToken(token, "name"); err != nil || data != "weloe" {
+		t.Errorf("GetExtraDataByToken() failed: data = %v, err = %v", data, err)
+	}
+	if session := enforcer.GetSession("1"); session == nil || session.GetLastTokenByDevice("pc") != token {
+		t.Errorf("GetSession() failed: %+v", session)
+	}
+	if ok, err := enforcer.IsLoginByToken(token); !ok || err != nil {
+		t.Errorf("IsLoginByToken() failed: %v", err)
+	}
+
+	// valid signature but not registered in session
+	stateless, err := createToken("user", "1", "pc", 60, nil, "123")
+	if err != nil {
+		t.Fatalf("createToken() failed: %v", err)
+	}
+	if _, err = enforcer.GetIdByToken(stateless); err == nil {
+		t.Errorf("GetIdByToken() should reject token without session")
+	}
+
+	err = enforcer.LogoutByToken(token)
+	if err != nil {
+		t.Fatalf("LogoutByToken() failed: %v", err)
+	}
+	if _, err = enforcer.GetIdByToken(token); err == nil {
+		t.Errorf("GetIdByToken() should reject logged out token")
+	}
+	if enforcer.GetSession("1") != nil {
+		t.Errorf("session should be deleted after logout")
+	}
+}
+
+func TestMixedEnforcer_Kickout(t *testing.T) {
+	enforcer := newTestMixedEnforcer(t)
+
+	token, err := enforcer.LoginByModel("1", &model.Login{Device: "pc", Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	err = enforcer.Kickout("1", "pc")
+	if err != nil {
+		t.Fatalf("Kickout() failed: %v", err)
+	}
+	if _, err = enforcer.GetIdByToken(token); !errors.Is(err, tokenErrors.BeKicked) {
+		t.Errorf("GetIdByToken() error = %v, want BeKicked", err)
+	}
+}
+
+func TestMixedEnforcer_Replaced(t *testing.T) {
+	tokenConfig := config.DefaultTokenConfig()
+	tokenConfig.IsConcurrent = false
+	enforcer := newTestMixedEnforcer(t, tokenConfig)
+
+	first, err := enforcer.LoginByModel("1", &model.Login{Device: "pc", Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	second, err := enforcer.LoginByModel("1", &model.Login{Device: "pc", Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	if _, err = enforcer.GetIdByToken(first); !errors.Is(err, tokenErrors.BeReplaced) {
+		t.Errorf("GetIdByToken() error = %v, want BeReplaced", err)
+	}
+	if _, err = enforcer.GetIdByToken(second); err != nil {
+		t.Errorf("GetIdByToken() failed: %v", err)
+	}
+}
+
+func TestMixedEnforcer_Refresh(t *testing.T) {
+	enforcer := newTestMixedEnforcer(t)
+
+	pair, err := enforcer.LoginWithRefreshToken("1", &model.Login{Device: "pc", Timeout: 60}, 120, nil)
+	if err != nil {
+		t.Fatalf("LoginWithRefreshToken() failed: %v", err)
+	}
+	pair, err = enforcer.Refresh(pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() failed: %v", err)
+	}
+	if id, err := enforcer.GetIdByToken(pair.AccessToken); err != nil || id != "1" {
+		t.Errorf("GetIdByToken() refreshed token failed: id = %v, err = %v", id, err)
+	}
+}
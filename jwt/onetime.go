@@ -0,0 +1,158 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/weloe/token-go/persist"
+	"math"
+	"sync"
+	"time"
+)
+
+/* one-time token payload key */
+const (
+	// ONE_TIME_TOKEN value of TOKEN_TYPE
+	ONE_TIME_TOKEN = "oneTime"
+	// PURPOSE what the one-time token is used for, such as reset-password
+	PURPOSE = "purpose"
+	// BeConsumed consumed one-time token mark
+	BeConsumed = "consumed"
+)
+
+// AtomicAdapter persist.Adapter which can set a string only if the key does not exist, such as redis SETNX.
+// ConsumeToken needs it to reject the second use of token across nodes
+type AtomicAdapter interface {
+	persist.Adapter
+	// SetStrIfAbsent set value only if key does not exist, return false if key exists
+	SetStrIfAbsent(key string, value string, timeout int64) (bool, error)
+}
+
+// localConsumeLock make persist.DefaultAdapter atomic, its data is only in this process
+var localConsumeLock sync.Mutex
+
+// OneTimeOptions options of one-time token
+type OneTimeOptions struct {
+	// Purpose required, token can only be consumed with the same purpose
+	Purpose string
+	// NotBefore token is valid from NotBefore, default is now
+	NotBefore time.Time
+	// Timeout seconds token is valid after NotBefore, required
+	Timeout int64
+	// ExtraData set to extraData claim
+	ExtraData map[string]interface{}
+}
+
+// CreateOneTimeToken create a token which can be consumed only once by ConsumeToken, such as email verification
+// and password reset links. It can not be used as access token
+func (s *StatelessEnforcer) CreateOneTimeToken(id string, options OneTimeOptions) (string, error) {
+	if options.Purpose == "" {
+		return "", errors.New("arg options.Purpose can not be empty")
+	}
+	if options.Timeout <= 0 {
+		return "", errors.New("arg options.Timeout must be greater than 0")
+	}
+	now := s.validator.now()
+	notBefore := options.NotBefore
+	if notBefore.IsZero() || notBefore.Before(now) {
+		notBefore = now
+	}
+	// exp is counted from nbf
+	delay := int64(math.Ceil(notBefore.Sub(now).Seconds()))
+	claims, err := s.newClaims(id, "", delay+options.Timeout, options.ExtraData)
+	if err != nil {
+		return "", err
+	}
+	claims[TOKEN_TYPE] = ONE_TIME_TOKEN
+	claims[PURPOSE] = options.Purpose
+	claims[NOT_BEFORE] = notBefore.Unix()
+	return s.generateToken(claims)
+}
+
+// ConsumeToken verify the one-time token and mark it consumed, return JWT payload.
+// The jti is recorded until the token expires, using it again returns ErrRevoked.
+// The adapter must implement AtomicAdapter unless it is persist.DefaultAdapter
+func (s *StatelessEnforcer) ConsumeToken(token string, purpose string) (jwt.MapClaims, error) {
+	if !s.isAdapterEnabled() {
+		return nil, errors.New("one-time token needs a persist.Adapter, please call SetAdapter()")
+	}
+	payloads, err := s.parseOneTimeToken(token, purpose)
+	if err != nil {
+		return nil, err
+	}
+	jti := getJti(payloads)
+	if jti == "" {
+		return nil, newTokenError(ErrInvalidClaims, token, errors.New("invalid jti"))
+	}
+
+	timeout, err := calTimeout(token, payloads, s.validator.now())
+	if err != nil {
+		return nil, err
+	}
+	// expired token is accepted within leeway
+	timeout += int64(math.Ceil(s.GetLeeway().Seconds()))
+	if timeout <= 0 {
+		timeout = 1
+	}
+	ok, err := s.setStrIfAbsent(s.spliceConsumedKey(jti), BeConsumed, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, newTokenError(ErrRevoked, token, errors.New("one-time token has been consumed"))
+	}
+	return payloads, nil
+}
+
+// parseOneTimeToken verify one-time token, its purpose and nbf
+func (s *StatelessEnforcer) parseOneTimeToken(token string, purpose string) (jwt.MapClaims, error) {
+	payloads, err := s.verifyToken(token, s.GetType(), true)
+	if err != nil {
+		return nil, err
+	}
+	if payloads[TOKEN_TYPE] != ONE_TIME_TOKEN {
+		return nil, newTokenError(ErrTokenTypeMismatch, token, nil)
+	}
+	if payloads[PURPOSE] != purpose {
+		return nil, newTokenError(ErrInvalidClaims, token, errors.New("one-time token purpose mismatch"))
+	}
+	// legacy layout does not verify nbf
+	nbf, ok := payloads[NOT_BEFORE].(float64)
+	if !ok {
+		return nil, newTokenError(ErrInvalidClaims, token, errors.New("invalid nbf"))
+	}
+	if s.validator.now().Add(s.GetLeeway()).Unix() < int64(nbf) {
+		return nil, newTokenError(ErrNotValidYet, token, jwt.ErrTokenNotValidYet)
+	}
+	err = s.checkRevoked(payloads)
+	if err != nil {
+		return nil, err
+	}
+	err = s.checkVersion(payloads)
+	if err != nil {
+		return nil, err
+	}
+	return payloads, nil
+}
+
+// setStrIfAbsent set value only if key does not exist, return false if key exists
+func (s *StatelessEnforcer) setStrIfAbsent(key string, value string, timeout int64) (bool, error) {
+	switch adapter := s.GetAdapter().(type) {
+	case AtomicAdapter:
+		return adapter.SetStrIfAbsent(key, value, timeout)
+	case *persist.DefaultAdapter:
+		localConsumeLock.Lock()
+		defer localConsumeLock.Unlock()
+		if adapter.GetStr(key) != "" {
+			return false, nil
+		}
+		return true, adapter.SetStr(key, value, timeout)
+	default:
+		return false, fmt.Errorf("adapter %T can not set value atomically, please implement AtomicAdapter", adapter)
+	}
+}
+
+// spliceConsumedKey splice consumed one-time token key
+func (s *StatelessEnforcer) spliceConsumedKey(jti string) string {
+	return s.GetTokenConfig().TokenName + ":" + s.GetType() + ":consumed:" + jti
+}
@@ -0,0 +1,136 @@
+package jwt
+
+import (
+	"errors"
+	"github.com/weloe/token-go/persist"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testAtomicAdapter count SetStrIfAbsent calls
+type testAtomicAdapter struct {
+	*persist.DefaultAdapter
+	mu    sync.Mutex
+	calls int
+}
+
+func (a *testAtomicAdapter) SetStrIfAbsent(key string, value string, timeout int64) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls++
+	if a.GetStr(key) != "" {
+		return false, nil
+	}
+	return true, a.SetStr(key, value, timeout)
+}
+
+// testPlainAdapter does not implement AtomicAdapter
+type testPlainAdapter struct {
+	*persist.DefaultAdapter
+}
+
+func TestStatelessEnforcer_ConsumeToken(t *testing.T) {
+	for _, mode := range []ClaimsMode{LegacyClaims, RegisteredClaims} {
+		enforcer, clock := newTestClockEnforcer(t, mode)
+		enforcer.SetAdapter(persist.NewDefaultAdapter())
+
+		token, err := enforcer.CreateOneTimeToken("1", OneTimeOptions{
+			Purpose:   "reset-password",
+			NotBefore: clock.Now().Add(time.Hour),
+			Timeout:   600,
+			ExtraData: map[string]interface{}{"email": "a@b.c"},
+		})
+		if err != nil {
+			t.Fatalf("CreateOneTimeToken() failed: %v", err)
+		}
+
+		if _, err = enforcer.ConsumeToken(token, "reset-password"); !errors.Is(err, ErrNotValidYet) {
+			t.Fatalf("ConsumeToken() before nbf: err = %v, want ErrNotValidYet", err)
+		}
+
+		clock.Add(time.Hour)
+		if _, err = enforcer.GetIdByToken(token); !errors.Is(err, ErrTokenTypeMismatch) {
+			t.Fatalf("GetIdByToken() err = %v, want ErrTokenTypeMismatch", err)
+		}
+		if _, err = enforcer.ConsumeToken(token, "verify-email"); !errors.Is(err, ErrInvalidClaims) {
+			t.Fatalf("ConsumeToken() with wrong purpose: err = %v, want ErrInvalidClaims", err)
+		}
+		claims, err := enforcer.ConsumeToken(token, "reset-password")
+		if err != nil {
+			t.Fatalf("ConsumeToken() failed: %v", err)
+		}
+		if id, _ := claimLoginId(claims); id != "1" {
+			t.Errorf("ConsumeToken() id = %v, want 1", id)
+		}
+		if v, _ := getExtraDataFromClaims(claims, "email"); v != "a@b.c" {
+			t.Errorf("ConsumeToken() extraData email = %v, want a@b.c", v)
+		}
+		if _, err = enforcer.ConsumeToken(token, "reset-password"); !errors.Is(err, ErrRevoked) {
+			t.Fatalf("ConsumeToken() twice: err = %v, want ErrRevoked", err)
+		}
+
+		expired, err := enforcer.CreateOneTimeToken("1", OneTimeOptions{Purpose: "reset-password", Timeout: 60})
+		if err != nil {
+			t.Fatalf("CreateOneTimeToken() failed: %v", err)
+		}
+		clock.Add(2 * time.Minute)
+		if _, err = enforcer.ConsumeToken(expired, "reset-password"); !errors.Is(err, ErrExpired) {
+			t.Fatalf("ConsumeToken() expired: err = %v, want ErrExpired", err)
+		}
+	}
+}
+
+func TestStatelessEnforcer_ConsumeTokenConcurrently(t *testing.T) {
+	enforcer := newTestAdapterEnforcer(t)
+	token, err := enforcer.CreateOneTimeToken("1", OneTimeOptions{Purpose: "verify-email", Timeout: 60})
+	if err != nil {
+		t.Fatalf("CreateOneTimeToken() failed: %v", err)
+	}
+
+	var consumed int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := enforcer.ConsumeToken(token, "verify-email"); err == nil {
+				atomic.AddInt32(&consumed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if consumed != 1 {
+		t.Fatalf("ConsumeToken() succeeded %v times, want 1", consumed)
+	}
+}
+
+func TestStatelessEnforcer_ConsumeTokenAdapter(t *testing.T) {
+	enforcer := newTestAdapterEnforcer(t)
+	adapter := &testAtomicAdapter{DefaultAdapter: persist.NewDefaultAdapter()}
+	enforcer.SetAdapter(adapter)
+	token, err := enforcer.CreateOneTimeToken("1", OneTimeOptions{Purpose: "verify-email", Timeout: 60})
+	if err != nil {
+		t.Fatalf("CreateOneTimeToken() failed: %v", err)
+	}
+	if _, err = enforcer.ConsumeToken(token, "verify-email"); err != nil {
+		t.Fatalf("ConsumeToken() failed: %v", err)
+	}
+	if adapter.calls != 1 {
+		t.Errorf("SetStrIfAbsent() calls = %v, want 1", adapter.calls)
+	}
+
+	enforcer.SetAdapter(&testPlainAdapter{persist.NewDefaultAdapter()})
+	if _, err = enforcer.ConsumeToken(token, "verify-email"); err == nil {
+		t.Fatalf("ConsumeToken() with non-atomic adapter should fail")
+	}
+
+	enforcer.SetAdapter(&persist.EmptyAdapter{})
+	if _, err = enforcer.ConsumeToken(token, "verify-email"); err == nil {
+		t.Fatalf("ConsumeToken() without adapter should fail")
+	}
+	if _, err = enforcer.CreateOneTimeToken("1", OneTimeOptions{Timeout: 60}); err == nil {
+		t.Fatalf("CreateOneTimeToken() without purpose should fail")
+	}
+}
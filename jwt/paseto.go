@@ -0,0 +1,217 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/chacha20"
+	"strings"
+)
+
+/*
+	PASETO v4 implementation, see https://github.com/paseto-standard/paseto-spec/blob/master/docs/01-Protocol-Versions/Version4.md
+*/
+
+/* PASETO v4 header */
+const (
+	PASETO_V4_LOCAL  = "v4.local."
+	PASETO_V4_PUBLIC = "v4.public."
+)
+
+const (
+	pasetoNonceSize = 32
+	pasetoMacSize   = 32
+	// PASETO_LOCAL_KEY_SIZE v4.local key size
+	PASETO_LOCAL_KEY_SIZE = 32
+)
+
+var pasetoEncoding = base64.RawURLEncoding
+
+// pasetoEncrypt v4.local encrypt message with 32 bytes key
+func pasetoEncrypt(key []byte, message []byte, footer []byte, implicit []byte) (string, error) {
+	if len(key) != PASETO_LOCAL_KEY_SIZE {
+		return "", errors.New("invalid PASETO v4.local key")
+	}
+	nonce := make([]byte, pasetoNonceSize)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+	encKey, nonce2, authKey, err := pasetoSplitKey(key, nonce)
+	if err != nil {
+		return "", err
+	}
+
+	cipher, err := chacha20.NewUnauthenticatedCipher(encKey, nonce2)
+	if err != nil {
+		return "", err
+	}
+	ciphertext := make([]byte, len(message))
+	cipher.XORKeyStream(ciphertext, message)
+
+	mac, err := pasetoMac(authKey, []byte(PASETO_V4_LOCAL), nonce, ciphertext, footer, implicit)
+	if err != nil {
+		return "", err
+	}
+
+	payload := make([]byte, 0, len(nonce)+len(ciphertext)+len(mac))
+	payload = append(payload, nonce...)
+	payload = append(payload, ciphertext...)
+	payload = append(payload, mac...)
+	return pasetoToken(PASETO_V4_LOCAL, payload, footer), nil
+}
+
+// pasetoDecrypt v4.local decrypt token with 32 bytes key
+func pasetoDecrypt(key []byte, token string, implicit []byte) ([]byte, error) {
+	if len(key) != PASETO_LOCAL_KEY_SIZE {
+		return nil, errors.New("invalid PASETO v4.local key")
+	}
+	payload, footer, err := pasetoParse(PASETO_V4_LOCAL, token)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) < pasetoNonceSize+pasetoMacSize {
+		return nil, newTokenError(ErrMalformed, token, errors.New("PASETO payload is too short"))
+	}
+	nonce := payload[:pasetoNonceSize]
+	ciphertext := payload[pasetoNonceSize : len(payload)-pasetoMacSize]
+	mac := payload[len(payload)-pasetoMacSize:]
+
+	encKey, nonce2, authKey, err := pasetoSplitKey(key, nonce)
+	if err != nil {
+		return nil, err
+	}
+	expected, err := pasetoMac(authKey, []byte(PASETO_V4_LOCAL), nonce, ciphertext, footer, implicit)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(mac, expected) != 1 {
+		return nil, newTokenError(ErrInvalidSignature, token, nil)
+	}
+
+	cipher, err := chacha20.NewUnauthenticatedCipher(encKey, nonce2)
+	if err != nil {
+		return nil, err
+	}
+	message := make([]byte, len(ciphertext))
+	cipher.XORKeyStream(message, ciphertext)
+	return message, nil
+}
+
+// pasetoSign v4.public sign message with ed25519 private key
+func pasetoSign(privateKey ed25519.PrivateKey, message []byte, footer []byte, implicit []byte) (string, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return "", errors.New("invalid PASETO v4.public private key")
+	}
+	signature := ed25519.Sign(privateKey, pasetoPae([]byte(PASETO_V4_PUBLIC), message, footer, implicit))
+
+	payload := make([]byte, 0, len(message)+len(signature))
+	payload = append(payload, message...)
+	payload = append(payload, signature...)
+	return pasetoToken(PASETO_V4_PUBLIC, payload, footer), nil
+}
+
+// pasetoVerify v4.public verify token with ed25519 public key, return message
+func pasetoVerify(publicKey ed25519.PublicKey, token string, implicit []byte) ([]byte, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid PASETO v4.public public key")
+	}
+	payload, footer, err := pasetoParse(PASETO_V4_PUBLIC, token)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) < ed25519.SignatureSize {
+		return nil, newTokenError(ErrMalformed, token, errors.New("PASETO payload is too short"))
+	}
+	message := payload[:len(payload)-ed25519.SignatureSize]
+	signature := payload[len(payload)-ed25519.SignatureSize:]
+	if !ed25519.Verify(publicKey, pasetoPae([]byte(PASETO_V4_PUBLIC), message, footer, implicit), signature) {
+		return nil, newTokenError(ErrInvalidSignature, token, nil)
+	}
+	return message, nil
+}
+
+// pasetoSplitKey derive encryption key, XChaCha20 nonce and authentication key
+func pasetoSplitKey(key []byte, nonce []byte) ([]byte, []byte, []byte, error) {
+	h, err := blake2b.New(56, key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	h.Write([]byte("paseto-encryption-key"))
+	h.Write(nonce)
+	tmp := h.Sum(nil)
+
+	h, err = blake2b.New(32, key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	h.Write([]byte("paseto-auth-key-for-aead"))
+	h.Write(nonce)
+	return tmp[:32], tmp[32:], h.Sum(nil), nil
+}
+
+func pasetoMac(authKey []byte, pieces ...[]byte) ([]byte, error) {
+	h, err := blake2b.New(pasetoMacSize, authKey)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(pasetoPae(pieces...))
+	return h.Sum(nil), nil
+}
+
+// pasetoPae pre-authentication encoding
+func pasetoPae(pieces ...[]byte) []byte {
+	buf := &bytes.Buffer{}
+	pasetoLe64(buf, len(pieces))
+	for _, piece := range pieces {
+		pasetoLe64(buf, len(piece))
+		buf.Write(piece)
+	}
+	return buf.Bytes()
+}
+
+func pasetoLe64(buf *bytes.Buffer, n int) {
+	b := make([]byte, 8)
+	// clear the most significant bit
+	binary.LittleEndian.PutUint64(b, uint64(n)&^(1<<63))
+	buf.Write(b)
+}
+
+func pasetoToken(header string, payload []byte, footer []byte) string {
+	token := header + pasetoEncoding.EncodeToString(payload)
+	if len(footer) > 0 {
+		token += "." + pasetoEncoding.EncodeToString(footer)
+	}
+	return token
+}
+
+// pasetoParse check header, return decoded payload and footer
+func pasetoParse(header string, token string) ([]byte, []byte, error) {
+	if token == "" {
+		return nil, nil, newTokenError(ErrMalformed, token, errors.New("PASETO string cannot be null"))
+	}
+	if !strings.HasPrefix(token, header) {
+		return nil, nil, newTokenError(ErrMalformed, token, errors.New("invalid PASETO header"))
+	}
+	parts := strings.Split(token[len(header):], ".")
+	if len(parts) > 2 {
+		return nil, nil, newTokenError(ErrMalformed, token, errors.New("invalid PASETO format"))
+	}
+	payload, err := pasetoEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, nil, newTokenError(ErrMalformed, token, err)
+	}
+	var footer []byte
+	if len(parts) == 2 {
+		footer, err = pasetoEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, nil, newTokenError(ErrMalformed, token, err)
+		}
+	}
+	return payload, footer, nil
+}
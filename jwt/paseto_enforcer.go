@@ -0,0 +1,349 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	tokenGo "github.com/weloe/token-go"
+	"github.com/weloe/token-go/config"
+	"github.com/weloe/token-go/ctx"
+	"github.com/weloe/token-go/log"
+	"github.com/weloe/token-go/model"
+	"github.com/weloe/token-go/persist"
+	"time"
+)
+
+var _ IEnforcer = (*PasetoEnforcer)(nil)
+
+// PasetoEnforcer use PASETO v4.local or v4.public implement, the payload is the same as JWT created by StatelessEnforcer.
+// PASETO has no alg header, the key decides the algorithm
+type PasetoEnforcer struct {
+	e *tokenGo.Enforcer
+	// header PASETO_V4_LOCAL or PASETO_V4_PUBLIC
+	header      string
+	localKey    []byte
+	privateKey  ed25519.PrivateKey
+	publicKey   ed25519.PublicKey
+	validator   *validator
+	authManager interface{}
+}
+
+// NewPasetoLocalEnforcer new v4.local enforcer, token is encrypted with 32 bytes key,
+// other parameter need TokenConfig or string
+func NewPasetoLocalEnforcer(key []byte, args ...interface{}) (*PasetoEnforcer, error) {
+	if len(key) != PASETO_LOCAL_KEY_SIZE {
+		return nil, errors.New("PASETO v4.local key must be 32 bytes")
+	}
+	p, err := newPasetoEnforcer(args...)
+	if err != nil {
+		return nil, err
+	}
+	p.header = PASETO_V4_LOCAL
+	p.localKey = append([]byte(nil), key...)
+	return p, nil
+}
+
+// NewPasetoPublicEnforcer new v4.public enforcer, key must be created by NewEd25519Key or NewEd25519PublicKey.
+// Enforcer with public key can only verify token, other parameter need TokenConfig or string
+func NewPasetoPublicEnforcer(key SigningKey, args ...interface{}) (*PasetoEnforcer, error) {
+	if key == nil {
+		return nil, errors.New("arg key can not be nil")
+	}
+	publicKey, ok := key.VerifyKey().(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("PASETO v4.public key must be ed25519 key")
+	}
+	p, err := newPasetoEnforcer(args...)
+	if err != nil {
+		return nil, err
+	}
+	p.header = PASETO_V4_PUBLIC
+	p.publicKey = publicKey
+	p.privateKey, _ = key.SignKey().(ed25519.PrivateKey)
+	return p, nil
+}
+
+func newPasetoEnforcer(args ...interface{}) (*PasetoEnforcer, error) {
+	var e *tokenGo.Enforcer
+	var err error
+	if len(args) > 0 {
+		e, err = tokenGo.NewEnforcer(&persist.EmptyAdapter{}, args[0])
+	} else {
+		e, err = tokenGo.NewEnforcer(&persist.EmptyAdapter{})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &PasetoEnforcer{e: e, validator: &validator{clock: time.Now}}, nil
+}
+
+// SetAuth set auth manager, roles, permissions and scopes are embedded in token when token is created
+func (p *PasetoEnforcer) SetAuth(manager interface{}) {
+	p.authManager = manager
+	p.e.SetAuth(manager)
+}
+
+// CheckRole check the token of request has role
+func (p *PasetoEnforcer) CheckRole(ctx ctx.Context, role string) error {
+	return p.checkAuthClaims(ctx, ROLES, role, matchStr)
+}
+
+// CheckPermission check the token of request has permission, granted permission supports wildcard such as user:*
+func (p *PasetoEnforcer) CheckPermission(ctx ctx.Context, permission string) error {
+	return p.checkAuthClaims(ctx, PERMISSIONS, permission, matchPermission)
+}
+
+func (p *PasetoEnforcer) checkAuthClaims(ctx ctx.Context, key string, required string, match func(granted string, required string) bool) error {
+	token := p.GetRequestToken(ctx)
+	if token == "" {
+		return errors.New("token is nil")
+	}
+	payloads, err := p.parseToken(token, true)
+	if err != nil {
+		return err
+	}
+	return matchAuthClaims(token, payloads, key, []string{required}, true, match)
+}
+
+func (p *PasetoEnforcer) GetAdapter() persist.Adapter {
+	return p.e.GetAdapter()
+}
+
+func (p *PasetoEnforcer) SetAdapter(adapter persist.Adapter) {
+	p.e.SetAdapter(adapter)
+}
+
+func (p *PasetoEnforcer) EnableLog() {
+	p.e.EnableLog()
+}
+
+func (p *PasetoEnforcer) IsLogEnable() bool {
+	return p.e.IsLogEnable()
+}
+
+func (p *PasetoEnforcer) GetTokenConfig() config.TokenConfig {
+	return p.e.GetTokenConfig()
+}
+
+func (p *PasetoEnforcer) SetType(t string) {
+	p.e.SetType(t)
+}
+
+func (p *PasetoEnforcer) GetType() string {
+	return p.e.GetType()
+}
+
+func (p *PasetoEnforcer) SetLogger(logger log.Logger) {
+	p.e.SetLogger(logger)
+}
+
+func (p *PasetoEnforcer) GetLogger() log.Logger {
+	return p.e.GetLogger()
+}
+
+func (p *PasetoEnforcer) SetWatcher(watcher persist.Watcher) {
+	p.e.SetWatcher(watcher)
+}
+
+func (p *PasetoEnforcer) GetWatcher() persist.Watcher {
+	return p.e.GetWatcher()
+}
+
+// SetSecretKey only store secret in TokenConfig, PASETO key is set by constructor
+func (p *PasetoEnforcer) SetSecretKey(secret string) {
+	p.e.SetJwtSecretKey(secret)
+}
+
+func (p *PasetoEnforcer) GetSecretKey() string {
+	return p.e.GetTokenConfig().JwtSecretKey
+}
+
+// SetClock set the clock used to create and verify token, default is time.Now
+func (p *PasetoEnforcer) SetClock(clock Clock) {
+	p.validator.clock = clock
+}
+
+// SetLeeway set the tolerance of expiration check for clock skew between nodes
+func (p *PasetoEnforcer) SetLeeway(leeway time.Duration) {
+	p.validator.leeway = leeway
+}
+
+// Login loginById and loginModel, return tokenValue and error
+// ctx.Context can be nil
+func (p *PasetoEnforcer) Login(id string, ctx ctx.Context) (string, error) {
+	return p.LoginByModel(id, model.DefaultLoginModel(), ctx)
+}
+
+// LoginByModel login by id and loginModel, return tokenValue and error
+// ctx.Context can be nil
+func (p *PasetoEnforcer) LoginByModel(id string, loginModel *model.Login, ctx ctx.Context) (string, error) {
+	if loginModel == nil {
+		return "", errors.New("arg loginModel can not be nil")
+	}
+	token, err := p.createToken(id, loginModel.Device, loginModel.Timeout, loginModel.JwtData)
+	if err != nil {
+		return "", err
+	}
+
+	err = p.e.ResponseToken(token, loginModel, ctx)
+	if err != nil {
+		return "", err
+	}
+
+	m := &model.Login{
+		Device:          loginModel.Device,
+		IsLastingCookie: loginModel.IsLastingCookie,
+		Timeout:         loginModel.Timeout,
+		JwtData:         loginModel.JwtData,
+		Token:           token,
+		IsWriteHeader:   loginModel.IsWriteHeader,
+	}
+
+	// called logger
+	p.e.GetLogger().Login(p.e.GetType(), id, token, m)
+
+	// called watcher
+	if p.e.GetWatcher() != nil {
+		p.e.GetWatcher().Login(p.e.GetType(), id, token, m)
+	}
+
+	return token, nil
+}
+
+// GetRequestToken get token from request
+func (p *PasetoEnforcer) GetRequestToken(ctx ctx.Context) string {
+	return p.e.GetRequestToken(ctx)
+}
+
+// GetClaims get claims by web context
+func (p *PasetoEnforcer) GetClaims(ctx ctx.Context) (jwt.Claims, error) {
+	token := p.GetRequestToken(ctx)
+	if token == "" {
+		return nil, errors.New("token is nil")
+	}
+	return p.GetClaimsByToken(token)
+}
+
+// GetExtraData get extra data by web context
+func (p *PasetoEnforcer) GetExtraData(ctx ctx.Context, key string) (interface{}, error) {
+	token := p.GetRequestToken(ctx)
+	if token == "" {
+		return nil, errors.New("token is nil")
+	}
+	return p.GetExtraDataByToken(token, key)
+}
+
+// GetClaimsByToken get token claims, exp and iat are converted to numeric dates as JWT
+func (p *PasetoEnforcer) GetClaimsByToken(token string) (jwt.Claims, error) {
+	return p.parseToken(token, true)
+}
+
+// GetExtraDataByToken parse extraData map
+func (p *PasetoEnforcer) GetExtraDataByToken(token string, key string) (interface{}, error) {
+	payloads, err := p.parseToken(token, true)
+	if err != nil {
+		return nil, err
+	}
+	return getExtraDataFromClaims(payloads, key)
+}
+
+func (p *PasetoEnforcer) GetLoginId(ctx ctx.Context) (string, error) {
+	token := p.GetRequestToken(ctx)
+	return p.GetIdByToken(token)
+}
+
+// GetIdByToken parse token and get id
+func (p *PasetoEnforcer) GetIdByToken(token string) (string, error) {
+	payloads, err := p.parseToken(token, true)
+	if err != nil {
+		return "", err
+	}
+	return getIdFromClaims(token, payloads)
+}
+
+// GetTokenTimeout parse and get token timeout
+func (p *PasetoEnforcer) GetTokenTimeout(token string) (int64, error) {
+	payloads, err := p.parseToken(token, false)
+	if err != nil {
+		return 0, err
+	}
+	return calTimeout(token, payloads, p.validator.now())
+}
+
+// createToken create token with the same payload as createToken of JWT,
+// exp and iat are also set in RFC 3339 format as PASETO registered claims
+func (p *PasetoEnforcer) createToken(id string, device string, timeout int64, extraData map[string]interface{}) (string, error) {
+	now := p.validator.now()
+	claims, err := newClaims(p.GetType(), id, device, timeout, extraData, now)
+	if err != nil {
+		return "", err
+	}
+	addAuthClaims(claims, p.authManager, id)
+	claims[ISSUED_AT] = now.UTC().Format(time.RFC3339)
+	if timeout > NEVER_EXPIRE {
+		claims[EXPIRES_AT] = now.Add(time.Duration(timeout) * time.Second).UTC().Format(time.RFC3339)
+	}
+
+	message, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	if p.header == PASETO_V4_LOCAL {
+		return pasetoEncrypt(p.localKey, message, nil, nil)
+	}
+	if p.privateKey == nil {
+		return "", errors.New("the signing key can not sign token")
+	}
+	return pasetoSign(p.privateKey, message, nil, nil)
+}
+
+// toNumericDates convert RFC 3339 registered claims of PASETO to numeric dates as JWT,
+// so the returned jwt.MapClaims works with GetExpirationTime, GetIssuedAt and GetNotBefore
+func toNumericDates(payloads jwt.MapClaims) error {
+	for _, key := range []string{EXPIRES_AT, ISSUED_AT, NOT_BEFORE} {
+		value, ok := payloads[key].(string)
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("invalid %v: %w", key, err)
+		}
+		payloads[key] = float64(t.Unix())
+	}
+	return nil
+}
+
+// parseToken decrypt or verify token, verify login type and expiration time, return payload
+func (p *PasetoEnforcer) parseToken(token string, isCheckTimeout bool) (jwt.MapClaims, error) {
+	var message []byte
+	var err error
+	if p.header == PASETO_V4_LOCAL {
+		message, err = pasetoDecrypt(p.localKey, token, nil)
+	} else {
+		message, err = pasetoVerify(p.publicKey, token, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	payloads := jwt.MapClaims{}
+	err = json.Unmarshal(message, &payloads)
+	if err != nil {
+		return nil, newTokenError(ErrMalformed, token, err)
+	}
+	err = toNumericDates(payloads)
+	if err != nil {
+		return nil, newTokenError(ErrInvalidClaims, token, err)
+	}
+	if payloads[LOGIN_TYPE] != p.GetType() {
+		return nil, newTokenError(ErrLoginTypeMismatch, token, nil)
+	}
+	err = p.validator.validate(token, payloads, isCheckTimeout)
+	if err != nil {
+		return nil, classifyError(token, err)
+	}
+	return payloads, nil
+}
@@ -0,0 +1,150 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"github.com/weloe/token-go/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestPasetoEnforcers(t *testing.T) map[string]*PasetoEnforcer {
+	key := make([]byte, PASETO_LOCAL_KEY_SIZE)
+	_, _ = rand.Read(key)
+	local, err := NewPasetoLocalEnforcer(key)
+	if err != nil {
+		t.Fatalf("NewPasetoLocalEnforcer() failed: %v", err)
+	}
+	_, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	signingKey, _ := NewEd25519Key(privateKey)
+	public, err := NewPasetoPublicEnforcer(signingKey)
+	if err != nil {
+		t.Fatalf("NewPasetoPublicEnforcer() failed: %v", err)
+	}
+	return map[string]*PasetoEnforcer{PASETO_V4_LOCAL: local, PASETO_V4_PUBLIC: public}
+}
+
+func TestNewPasetoEnforcer(t *testing.T) {
+	if _, err := NewPasetoLocalEnforcer([]byte("123")); err == nil {
+		t.Errorf("NewPasetoLocalEnforcer() should reject short key")
+	}
+	if _, err := NewPasetoPublicEnforcer(NewHMACKey("123")); err == nil {
+		t.Errorf("NewPasetoPublicEnforcer() should reject HMAC key")
+	}
+}
+
+func TestPasetoEnforcer_Login(t *testing.T) {
+	for header, enforcer := range newTestPasetoEnforcers(t) {
+		clock := &testClock{now: time.Now()}
+		enforcer.SetClock(clock.Now)
+
+		token, err := enforcer.LoginByModel("1", &model.Login{Device: "pc", Timeout: 60, JwtData: map[string]interface{}{"name": "weloe"}}, nil)
+		if err != nil {
+			t.Fatalf("%v LoginByModel() failed: %v", header, err)
+		}
+		if !strings.HasPrefix(token, header) {
+			t.Errorf("%v unexpected token: %v", header, token)
+		}
+		if id, err := enforcer.GetIdByToken(token); err != nil || id != "1" {
+			t.Errorf("%v GetIdByToken() failed: id = %v, err = %v", header, id, err)
+		}
+		if data, err := enforcer.GetExtraDataByToken(token, "name"); err != nil || data != "weloe" {
+			t.Errorf("%v GetExtraDataByToken() failed: data = %v, err = %v", header, data, err)
+		}
+		if name, err := GetExtraDataAs[string](enforcer, token, "name"); err != nil || name != "weloe" {
+			t.Errorf("%v GetExtraDataAs() failed: name = %v, err = %v", header, name, err)
+		}
+		if timeout, err := enforcer.GetTokenTimeout(token); err != nil || timeout != 60 {
+			t.Errorf("%v GetTokenTimeout() failed: timeout = %v, err = %v", header, timeout, err)
+		}
+		if _, err = enforcer.GetIdByToken(token + "a"); err == nil {
+			t.Errorf("%v GetIdByToken() should reject tampered token", header)
+		}
+
+		enforcer.SetType("admin")
+		if _, err = enforcer.GetIdByToken(token); !errors.Is(err, ErrLoginTypeMismatch) {
+			t.Errorf("%v GetIdByToken() error = %v, want ErrLoginTypeMismatch", header, err)
+		}
+		enforcer.SetType("user")
+
+		clock.Add(61 * time.Second)
+		if _, err = enforcer.GetIdByToken(token); !errors.Is(err, ErrExpired) {
+			t.Errorf("%v GetIdByToken() error = %v, want ErrExpired", header, err)
+		}
+	}
+}
+
+func TestPasetoEnforcer_PublicKey(t *testing.T) {
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	signingKey, _ := NewEd25519Key(privateKey)
+	issuer, err := NewPasetoPublicEnforcer(signingKey)
+	if err != nil {
+		t.Fatalf("NewPasetoPublicEnforcer() failed: %v", err)
+	}
+	verifyKey, _ := NewEd25519PublicKey(publicKey)
+	verifier, err := NewPasetoPublicEnforcer(verifyKey)
+	if err != nil {
+		t.Fatalf("NewPasetoPublicEnforcer() failed: %v", err)
+	}
+
+	token, err := issuer.Login("1", nil)
+	if err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+	if id, err := verifier.GetIdByToken(token); err != nil || id != "1" {
+		t.Errorf("GetIdByToken() failed: id = %v, err = %v", id, err)
+	}
+	if _, err = verifier.Login("1", nil); err == nil {
+		t.Errorf("Login() should fail with public key")
+	}
+}
+
+func TestPasetoEnforcer_CheckRole(t *testing.T) {
+	enforcer := newTestPasetoEnforcers(t)[PASETO_V4_LOCAL]
+	enforcer.SetAuth(&testAuthManager{})
+	token, err := enforcer.Login("1", nil)
+	if err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+	c, _ := newTestRequestContext(t, enforcer, token)
+	if err = enforcer.CheckRole(c, "admin"); err != nil {
+		t.Errorf("CheckRole() failed: %v", err)
+	}
+	if err = enforcer.CheckPermission(c, "user:add"); err != nil {
+		t.Errorf("CheckPermission() failed: %v", err)
+	}
+	if err = enforcer.CheckPermission(c, "goods:delete"); err == nil {
+		t.Errorf("CheckPermission() should fail")
+	}
+}
+
+func TestPasetoEnforcer_RegisteredClaims(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	enforcers := map[string]IEnforcer{}
+	for header, enforcer := range newTestPasetoEnforcers(t) {
+		enforcer.SetClock(func() time.Time { return now })
+		enforcers[header] = enforcer
+	}
+	jwtEnforcer, _ := newTestClockEnforcer(t, RegisteredClaims)
+	jwtEnforcer.SetClock(func() time.Time { return now })
+	enforcers["jwt"] = jwtEnforcer
+
+	for name, enforcer := range enforcers {
+		token, err := enforcer.LoginByModel("1", &model.Login{Timeout: 60}, nil)
+		if err != nil {
+			t.Fatalf("%v LoginByModel() failed: %v", name, err)
+		}
+		claims, err := enforcer.GetClaimsByToken(token)
+		if err != nil {
+			t.Fatalf("%v GetClaimsByToken() failed: %v", name, err)
+		}
+		if exp, err := claims.GetExpirationTime(); err != nil || exp == nil || !exp.Equal(now.Add(time.Minute)) {
+			t.Errorf("%v GetExpirationTime() = %v, err = %v", name, exp, err)
+		}
+		if iat, err := claims.GetIssuedAt(); err != nil || iat == nil || !iat.Equal(now) {
+			t.Errorf("%v GetIssuedAt() = %v, err = %v", name, iat, err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestPasetoV4Public(t *testing.T) {
+	// test vector 4-S-1 of PASETO spec
+	privateKey, _ := hex.DecodeString("b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a37741eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2")
+	message := `{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`
+	want := "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA"
+
+	token, err := pasetoSign(privateKey, []byte(message), nil, nil)
+	if err != nil || token != want {
+		t.Fatalf("pasetoSign() = %v, err = %v", token, err)
+	}
+	publicKey := ed25519.PrivateKey(privateKey).Public().(ed25519.PublicKey)
+	got, err := pasetoVerify(publicKey, token, nil)
+	if err != nil || string(got) != message {
+		t.Errorf("pasetoVerify() = %s, err = %v", got, err)
+	}
+	if _, err = pasetoVerify(publicKey, token[:len(token)-2]+"AA", nil); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("pasetoVerify() error = %v, want ErrInvalidSignature", err)
+	}
+	if _, err = pasetoVerify(publicKey, token, []byte("implicit")); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("pasetoVerify() should bind implicit assertion: %v", err)
+	}
+}
+
+func TestPasetoV4Local(t *testing.T) {
+	// test vector 4-E-1 of PASETO spec
+	key, _ := hex.DecodeString("707172737475767778797a7b7c7d7e7f808182838485868788898a8b8c8d8e8f")
+	message := `{"data":"this is a secret message","exp":"2022-01-01T00:00:00+00:00"}`
+	token := "v4.local.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAr68PS4AXe7If_ZgesdkUMvSwscFlAl1pk5HC0e8kApeaqMfGo_7OpBnwJOAbY9V7WU6abu74MmcUE8YWAiaArVI8XJ5hOb_4v9RmDkneN0S92dx0OW4pgy7omxgf3S8c3LlQg"
+
+	got, err := pasetoDecrypt(key, token, nil)
+	if err != nil || string(got) != message {
+		t.Fatalf("pasetoDecrypt() = %s, err = %v", got, err)
+	}
+
+	token, err = pasetoEncrypt(key, []byte(message), []byte("kid"), nil)
+	if err != nil {
+		t.Fatalf("pasetoEncrypt() failed: %v", err)
+	}
+	got, err = pasetoDecrypt(key, token, nil)
+	if err != nil || string(got) != message {
+		t.Errorf("pasetoDecrypt() = %s, err = %v", got, err)
+	}
+	other := make([]byte, PASETO_LOCAL_KEY_SIZE)
+	if _, err = pasetoDecrypt(other, token, nil); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("pasetoDecrypt() error = %v, want ErrInvalidSignature", err)
+	}
+	if _, err = pasetoDecrypt(key, "v4.public."+token[len(PASETO_V4_LOCAL):], nil); !errors.Is(err, ErrMalformed) {
+		t.Errorf("pasetoDecrypt() error = %v, want ErrMalformed", err)
+	}
+}
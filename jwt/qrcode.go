@@ -0,0 +1,215 @@
+package jwt
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/weloe/token-go/ctx"
+	"github.com/weloe/token-go/model"
+	"math"
+)
+
+/*
+	stateless QR code login:
+	1. CreateQRTicket, the ticket is shown as QR code by the client which wants to login
+	2. ConfirmQRTicket, the logged-in device scans the QR code and gets a confirmation bound to the ticket,
+	   the confirmation is passed to the polling client, such as by push or the polling response
+	3. ExchangeQRConfirmation, the polling client exchanges ticket and confirmation for a session token
+	Only the used or canceled mark of ticket is stored in adapter
+*/
+
+/* QR code payload key */
+const (
+	// QR_TICKET value of TOKEN_TYPE
+	QR_TICKET = "qrTicket"
+	// QR_CONFIRMATION value of TOKEN_TYPE
+	QR_CONFIRMATION = "qrConfirmation"
+	// TICKET jti of the QR ticket which confirmation is bound to
+	TICKET = "ticket"
+	// BeUsed used QR ticket mark
+	BeUsed = "used"
+	// BeCanceled canceled QR ticket mark
+	BeCanceled = "canceled"
+)
+
+// QRCodeOptions QR code login options
+type QRCodeOptions struct {
+	// Timeout seconds of QR ticket, default is 120
+	Timeout int64
+	// ConfirmTimeout seconds of confirmation, it never outlives the ticket, default is 30
+	ConfirmTimeout int64
+}
+
+func initQRCodeOptions(options *QRCodeOptions) {
+	if options.Timeout <= 0 {
+		options.Timeout = 120
+	}
+	if options.ConfirmTimeout <= 0 {
+		options.ConfirmTimeout = 30
+	}
+}
+
+// SetQRCodeOptions set QR code login options
+func (s *StatelessEnforcer) SetQRCodeOptions(options QRCodeOptions) {
+	initQRCodeOptions(&options)
+	s.qrCodeOptions = options
+}
+
+// GetQRCodeOptions get QR code login options
+func (s *StatelessEnforcer) GetQRCodeOptions() QRCodeOptions {
+	options := s.qrCodeOptions
+	initQRCodeOptions(&options)
+	return options
+}
+
+// CreateQRTicket create a signed QR ticket, it does not belong to any loginId
+func (s *StatelessEnforcer) CreateQRTicket() (string, error) {
+	claims, err := newRegisteredClaims(s.GetType(), "", "", s.GetQRCodeOptions().Timeout, nil, s.claimsOptions, s.validator.now())
+	if err != nil {
+		return "", err
+	}
+	delete(claims, SUBJECT)
+	delete(claims, DEVICE)
+	claims[TOKEN_TYPE] = QR_TICKET
+	return s.generateToken(claims)
+}
+
+// ConfirmQRTicket confirm the QR ticket by the logged-in device of ctx, return a short-lived confirmation
+// which is bound to the ticket. The confirmation is exchanged for a session token by ExchangeQRConfirmation
+func (s *StatelessEnforcer) ConfirmQRTicket(ticket string, ctx ctx.Context) (string, error) {
+	if !s.isAdapterEnabled() {
+		return "", errors.New("QR code login needs a persist.Adapter, please call SetAdapter()")
+	}
+	id, err := s.GetLoginId(ctx)
+	if err != nil {
+		return "", err
+	}
+	ticketPayloads, err := s.parseQRTicket(ticket)
+	if err != nil {
+		return "", err
+	}
+	ticketTimeout, err := calTimeout(ticket, ticketPayloads, s.validator.now())
+	if err != nil {
+		return "", err
+	}
+	timeout := s.GetQRCodeOptions().ConfirmTimeout
+	if ticketTimeout < timeout {
+		timeout = ticketTimeout
+	}
+	if timeout <= 0 {
+		// less than one second left
+		timeout = 1
+	}
+
+	claims, err := s.newClaims(id, "", timeout, nil)
+	if err != nil {
+		return "", err
+	}
+	claims[TOKEN_TYPE] = QR_CONFIRMATION
+	claims[TICKET] = getJti(ticketPayloads)
+	return s.generateToken(claims)
+}
+
+// CancelQRTicket cancel the QR ticket by the logged-in device of ctx, the ticket can not be exchanged any more
+func (s *StatelessEnforcer) CancelQRTicket(ticket string, ctx ctx.Context) error {
+	if !s.isAdapterEnabled() {
+		return errors.New("QR code login needs a persist.Adapter, please call SetAdapter()")
+	}
+	if _, err := s.GetLoginId(ctx); err != nil {
+		return err
+	}
+	payloads, err := s.parseQRTicket(ticket)
+	if err != nil {
+		return err
+	}
+	return s.markQRTicket(ticket, payloads, BeCanceled)
+}
+
+// ExchangeQRConfirmation verify the ticket and its confirmation, login by the loginId of confirmation.
+// The ticket can be exchanged only once
+func (s *StatelessEnforcer) ExchangeQRConfirmation(ticket string, confirmation string, loginModel *model.Login, ctx ctx.Context) (string, error) {
+	if loginModel == nil {
+		return "", errors.New("arg loginModel can not be nil")
+	}
+	if !s.isAdapterEnabled() {
+		return "", errors.New("QR code login needs a persist.Adapter, please call SetAdapter()")
+	}
+	ticketPayloads, err := s.parseQRTicket(ticket)
+	if err != nil {
+		return "", err
+	}
+	payloads, err := s.verifyToken(confirmation, s.GetType(), true)
+	if err != nil {
+		return "", err
+	}
+	if payloads[TOKEN_TYPE] != QR_CONFIRMATION {
+		return "", newTokenError(ErrTokenTypeMismatch, confirmation, nil)
+	}
+	if payloads[TICKET] != getJti(ticketPayloads) {
+		return "", newTokenError(ErrInvalidClaims, confirmation, errors.New("confirmation is not bound to the QR ticket"))
+	}
+	// the confirming device may have logged out
+	err = s.checkRevoked(payloads)
+	if err != nil {
+		return "", err
+	}
+	err = s.checkVersion(payloads)
+	if err != nil {
+		return "", err
+	}
+	id, err := getIdFromClaims(confirmation, payloads)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.markQRTicket(ticket, ticketPayloads, BeUsed)
+	if err != nil {
+		return "", err
+	}
+	return s.LoginByModel(id, loginModel, ctx)
+}
+
+// parseQRTicket verify QR ticket, return error if it is used or canceled
+func (s *StatelessEnforcer) parseQRTicket(ticket string) (jwt.MapClaims, error) {
+	payloads, err := s.verifyToken(ticket, s.GetType(), true)
+	if err != nil {
+		return nil, err
+	}
+	if payloads[TOKEN_TYPE] != QR_TICKET {
+		return nil, newTokenError(ErrTokenTypeMismatch, ticket, nil)
+	}
+	jti := getJti(payloads)
+	if jti == "" {
+		return nil, newTokenError(ErrInvalidClaims, ticket, errors.New("invalid jti"))
+	}
+	if mark := s.GetAdapter().GetStr(s.spliceQRKey(jti)); mark != "" {
+		return nil, newTokenError(ErrRevoked, ticket, errors.New("QR ticket has been "+mark))
+	}
+	return payloads, nil
+}
+
+// markQRTicket record the jti of ticket with mark atomically until the ticket expires
+func (s *StatelessEnforcer) markQRTicket(ticket string, payloads jwt.MapClaims, mark string) error {
+	timeout, err := calTimeout(ticket, payloads, s.validator.now())
+	if err != nil {
+		return err
+	}
+	// expired ticket is accepted within leeway
+	timeout += int64(math.Ceil(s.GetLeeway().Seconds()))
+	if timeout <= 0 {
+		timeout = 1
+	}
+	jti := getJti(payloads)
+	ok, err := s.setStrIfAbsent(s.spliceQRKey(jti), mark, timeout)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return newTokenError(ErrRevoked, ticket, errors.New("QR ticket has been "+s.GetAdapter().GetStr(s.spliceQRKey(jti))))
+	}
+	return nil
+}
+
+// spliceQRKey splice QR ticket key
+func (s *StatelessEnforcer) spliceQRKey(jti string) string {
+	return s.GetTokenConfig().TokenName + ":" + s.GetType() + ":qrTicket:" + jti
+}
@@ -0,0 +1,116 @@
+package jwt
+
+import (
+	"errors"
+	"github.com/weloe/token-go/model"
+	"github.com/weloe/token-go/persist"
+	"testing"
+	"time"
+)
+
+func TestStatelessEnforcer_QRCodeLogin(t *testing.T) {
+	for _, mode := range []ClaimsMode{LegacyClaims, RegisteredClaims} {
+		enforcer, clock := newTestClockEnforcer(t, mode)
+		enforcer.SetAdapter(persist.NewDefaultAdapter())
+
+		phoneToken, err := enforcer.LoginByModel("1", &model.Login{Device: "phone", Timeout: 600}, nil)
+		if err != nil {
+			t.Fatalf("LoginByModel() failed: %v", err)
+		}
+		phoneCtx, _ := newTestRequestContext(t, enforcer, phoneToken)
+
+		ticket, err := enforcer.CreateQRTicket()
+		if err != nil {
+			t.Fatalf("CreateQRTicket() failed: %v", err)
+		}
+		if _, err = enforcer.GetIdByToken(ticket); !errors.Is(err, ErrTokenTypeMismatch) {
+			t.Fatalf("mode %v GetIdByToken(ticket) err = %v, want ErrTokenTypeMismatch", mode, err)
+		}
+
+		confirmation, err := enforcer.ConfirmQRTicket(ticket, phoneCtx)
+		if err != nil {
+			t.Fatalf("mode %v ConfirmQRTicket() failed: %v", mode, err)
+		}
+		if _, err = enforcer.GetIdByToken(confirmation); !errors.Is(err, ErrTokenTypeMismatch) {
+			t.Fatalf("mode %v GetIdByToken(confirmation) err = %v, want ErrTokenTypeMismatch", mode, err)
+		}
+
+		other, err := enforcer.CreateQRTicket()
+		if err != nil {
+			t.Fatalf("CreateQRTicket() failed: %v", err)
+		}
+		if _, err = enforcer.ExchangeQRConfirmation(other, confirmation, &model.Login{Device: "pc"}, nil); !errors.Is(err, ErrInvalidClaims) {
+			t.Fatalf("mode %v ExchangeQRConfirmation() with other ticket: err = %v, want ErrInvalidClaims", mode, err)
+		}
+
+		token, err := enforcer.ExchangeQRConfirmation(ticket, confirmation, &model.Login{Device: "pc", Timeout: 60}, nil)
+		if err != nil {
+			t.Fatalf("mode %v ExchangeQRConfirmation() failed: %v", mode, err)
+		}
+		if id, err := enforcer.GetIdByToken(token); err != nil || id != "1" {
+			t.Fatalf("mode %v GetIdByToken() id = %v, err = %v", mode, id, err)
+		}
+		if payloads, _ := enforcer.parseToken(token, true); payloads[DEVICE] != "pc" {
+			t.Errorf("mode %v device = %v, want pc", mode, payloads[DEVICE])
+		}
+
+		if _, err = enforcer.ExchangeQRConfirmation(ticket, confirmation, &model.Login{Device: "pc"}, nil); !errors.Is(err, ErrRevoked) {
+			t.Fatalf("mode %v ExchangeQRConfirmation() twice: err = %v, want ErrRevoked", mode, err)
+		}
+		if _, err = enforcer.ConfirmQRTicket(ticket, phoneCtx); !errors.Is(err, ErrRevoked) {
+			t.Fatalf("mode %v ConfirmQRTicket() used ticket: err = %v, want ErrRevoked", mode, err)
+		}
+
+		// confirmation is short-lived
+		confirmation, err = enforcer.ConfirmQRTicket(other, phoneCtx)
+		if err != nil {
+			t.Fatalf("mode %v ConfirmQRTicket() failed: %v", mode, err)
+		}
+		clock.Add(31 * time.Second)
+		if _, err = enforcer.ExchangeQRConfirmation(other, confirmation, &model.Login{Device: "pc"}, nil); !errors.Is(err, ErrExpired) {
+			t.Fatalf("mode %v ExchangeQRConfirmation() expired: err = %v, want ErrExpired", mode, err)
+		}
+
+		if err = enforcer.CancelQRTicket(other, phoneCtx); err != nil {
+			t.Fatalf("mode %v CancelQRTicket() failed: %v", mode, err)
+		}
+		if _, err = enforcer.ConfirmQRTicket(other, phoneCtx); !errors.Is(err, ErrRevoked) {
+			t.Fatalf("mode %v ConfirmQRTicket() canceled ticket: err = %v, want ErrRevoked", mode, err)
+		}
+	}
+}
+
+func TestStatelessEnforcer_QRCodeOptions(t *testing.T) {
+	enforcer := newTestAdapterEnforcer(t)
+	if options := enforcer.GetQRCodeOptions(); options.Timeout != 120 || options.ConfirmTimeout != 30 {
+		t.Fatalf("GetQRCodeOptions() = %+v, want default", options)
+	}
+	enforcer.SetQRCodeOptions(QRCodeOptions{Timeout: 10, ConfirmTimeout: 60})
+
+	ticket, err := enforcer.CreateQRTicket()
+	if err != nil {
+		t.Fatalf("CreateQRTicket() failed: %v", err)
+	}
+	ctx, _ := newTestRequestContext(t, enforcer, "")
+	if _, err = enforcer.ConfirmQRTicket(ticket, ctx); err == nil {
+		t.Fatalf("ConfirmQRTicket() without login should fail")
+	}
+
+	phoneToken, err := enforcer.LoginByModel("1", model.DefaultLoginModel(), nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	ctx, _ = newTestRequestContext(t, enforcer, phoneToken)
+	confirmation, err := enforcer.ConfirmQRTicket(ticket, ctx)
+	if err != nil {
+		t.Fatalf("ConfirmQRTicket() failed: %v", err)
+	}
+	// confirmation never outlives the ticket
+	payloads, err := enforcer.verifyToken(confirmation, enforcer.GetType(), true)
+	if err != nil {
+		t.Fatalf("verifyToken() failed: %v", err)
+	}
+	if timeout, _ := calTimeout(confirmation, payloads, time.Now()); timeout > 10 {
+		t.Errorf("confirmation timeout = %v, want <= 10", timeout)
+	}
+}
@@ -0,0 +1,205 @@
+package jwt
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/weloe/token-go/ctx"
+	"github.com/weloe/token-go/model"
+	"github.com/weloe/token-go/util"
+	"time"
+)
+
+/* refresh token payload key */
+const (
+	// REFRESH_TOKEN value of TOKEN_TYPE
+	REFRESH_TOKEN = "refresh"
+	// FAMILY_ID all refresh tokens rotated from the same login share a family id
+	FAMILY_ID = "familyId"
+	// ACCESS_TIMEOUT timeout of the access token issued by refresh
+	ACCESS_TIMEOUT = "accessTimeout"
+)
+
+// TokenPair short-lived access token and long-lived refresh token
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	// AccessTimeout access token timeout, seconds
+	AccessTimeout int64
+	// RefreshTimeout refresh token timeout, seconds
+	RefreshTimeout int64
+}
+
+// LoginWithRefreshToken login by id and loginModel, return an access token with loginModel.Timeout
+// and a refresh token with refreshTimeout. The access token is responded to ctx.Context like LoginByModel.
+// Refresh token state is stored in adapter, ctx.Context can be nil
+func (s *StatelessEnforcer) LoginWithRefreshToken(id string, loginModel *model.Login, refreshTimeout int64, ctx ctx.Context) (*TokenPair, error) {
+	if loginModel == nil {
+		return nil, errors.New("arg loginModel can not be nil")
+	}
+	if !s.isAdapterEnabled() {
+		return nil, errors.New("refresh token needs a persist.Adapter, please call SetAdapter()")
+	}
+	familyId, err := util.GenerateRandomString32()
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := s.createRefreshToken(id, loginModel.Device, loginModel.Timeout, refreshTimeout, loginModel.JwtData, familyId)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := s.LoginByModel(id, loginModel, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:    accessToken,
+		RefreshToken:   refreshToken,
+		AccessTimeout:  loginModel.Timeout,
+		RefreshTimeout: refreshTimeout,
+	}, nil
+}
+
+// Refresh issue a new token pair and invalidate the refresh token.
+// If a refresh token which has been rotated is used again, the whole token family is revoked.
+// The refresh token is consumed atomically, so the adapter must implement AtomicAdapter unless it is persist.DefaultAdapter
+func (s *StatelessEnforcer) Refresh(refreshToken string) (*TokenPair, error) {
+	if !s.isAdapterEnabled() {
+		return nil, errors.New("refresh token needs a persist.Adapter, please call SetAdapter()")
+	}
+	payloads, err := s.parseRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	familyId, _ := payloads[FAMILY_ID].(string)
+	jti := getJti(payloads)
+	loginId, _ := claimLoginId(payloads)
+	device, _ := payloads[DEVICE].(string)
+	accessTimeout, _ := payloads[ACCESS_TIMEOUT].(float64)
+	extraData, _ := payloads[EXTRA_DATA].(map[string]interface{})
+
+	refreshTimeout := refreshTokenTimeout(payloads, s.validator.now())
+
+	// check the refresh token is the latest one of its family
+	current := s.GetAdapter().GetStr(s.spliceRefreshKey(familyId))
+	if current == "" || s.GetAdapter().GetStr(s.spliceRefreshKey(familyId)+":"+BeRevoked) != "" {
+		return nil, newTokenError(ErrRevoked, refreshToken, errors.New("refresh token has been revoked"))
+	}
+	reused := current != jti
+	if !reused {
+		// concurrent refresh with the same token, only one of them consumes it
+		consumed, err := s.setStrIfAbsent(s.spliceRefreshKey(familyId)+":"+jti, BeConsumed, refreshTimeout)
+		if err != nil {
+			return nil, err
+		}
+		reused = !consumed
+	}
+	if reused {
+		// reuse detected, revoke the whole family
+		err = s.revokeRefreshFamily(familyId, refreshTimeout)
+		if err != nil {
+			return nil, err
+		}
+		return nil, newTokenError(ErrRevoked, refreshToken, errors.New("refresh token reuse detected, token family has been revoked"))
+	}
+
+	newRefreshToken, err := s.createRefreshToken(loginId, device, int64(accessTimeout), refreshTimeout, extraData, familyId)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := s.createToken(loginId, device, int64(accessTimeout), extraData)
+	if err != nil {
+		return nil, err
+	}
+	if s.stateful {
+		_, err = s.loginSession(loginId, accessToken, &model.Login{Device: device, Timeout: int64(accessTimeout), JwtData: extraData}, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &TokenPair{
+		AccessToken:    accessToken,
+		RefreshToken:   newRefreshToken,
+		AccessTimeout:  int64(accessTimeout),
+		RefreshTimeout: refreshTimeout,
+	}, nil
+}
+
+// RevokeRefreshToken revoke the whole token family of refreshToken
+func (s *StatelessEnforcer) RevokeRefreshToken(refreshToken string) error {
+	if !s.isAdapterEnabled() {
+		return errors.New("refresh token needs a persist.Adapter, please call SetAdapter()")
+	}
+	payloads, err := s.parseRefreshToken(refreshToken)
+	if err != nil {
+		return err
+	}
+	familyId, _ := payloads[FAMILY_ID].(string)
+	return s.revokeRefreshFamily(familyId, refreshTokenTimeout(payloads, s.validator.now()))
+}
+
+// revokeRefreshFamily mark the token family revoked until its tokens expire,
+// the mark is kept even if a concurrent Refresh stores a new token of the family
+func (s *StatelessEnforcer) revokeRefreshFamily(familyId string, timeout int64) error {
+	err := s.GetAdapter().SetStr(s.spliceRefreshKey(familyId)+":"+BeRevoked, BeRevoked, timeout)
+	if err != nil {
+		return err
+	}
+	return s.GetAdapter().DeleteStr(s.spliceRefreshKey(familyId))
+}
+
+// createRefreshToken create refresh token and store it as the latest token of family
+func (s *StatelessEnforcer) createRefreshToken(id string, device string, accessTimeout int64, refreshTimeout int64, extraData map[string]interface{}, familyId string) (string, error) {
+	claims, err := s.newClaims(id, device, refreshTimeout, extraData)
+	if err != nil {
+		return "", err
+	}
+	claims[TOKEN_TYPE] = REFRESH_TOKEN
+	claims[FAMILY_ID] = familyId
+	claims[ACCESS_TIMEOUT] = accessTimeout
+
+	token, err := s.generateToken(claims)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.GetAdapter().SetStr(s.spliceRefreshKey(familyId), getJti(claims), refreshTimeout)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// parseRefreshToken verify refresh token and its claims
+func (s *StatelessEnforcer) parseRefreshToken(refreshToken string) (jwt.MapClaims, error) {
+	payloads, err := s.verifyToken(refreshToken, s.GetType(), true)
+	if err != nil {
+		return nil, err
+	}
+	if payloads[TOKEN_TYPE] != REFRESH_TOKEN {
+		return nil, newTokenError(ErrTokenTypeMismatch, refreshToken, nil)
+	}
+	if familyId, ok := payloads[FAMILY_ID].(string); !ok || familyId == "" {
+		return nil, newTokenError(ErrInvalidClaims, refreshToken, errors.New("invalid refresh token family"))
+	}
+	err = s.checkVersion(payloads)
+	if err != nil {
+		return nil, err
+	}
+	return payloads, nil
+}
+
+// refreshTokenTimeout the new refresh token expires at the same time as the old one,
+// so rotation does not extend the lifetime of the token family
+func refreshTokenTimeout(payloads jwt.MapClaims, now time.Time) int64 {
+	timeout, _ := calTimeout("", payloads, now)
+	if timeout <= 0 && timeout != NEVER_EXPIRE {
+		// less than one second left, or expired within leeway
+		return 1
+	}
+	return timeout
+}
+
+// spliceRefreshKey splice refresh token family key
+func (s *StatelessEnforcer) spliceRefreshKey(familyId string) string {
+	return s.GetTokenConfig().TokenName + ":" + s.GetType() + ":refresh:" + familyId
+}
@@ -0,0 +1,139 @@
+package jwt
+
+import (
+	"github.com/weloe/token-go/model"
+	"github.com/weloe/token-go/persist"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestAdapterEnforcer(t *testing.T) *StatelessEnforcer {
+	enforcer := newTestEnforcer(t)
+	enforcer.SetSecretKey("123")
+	enforcer.SetAdapter(persist.NewDefaultAdapter())
+	return enforcer
+}
+
+func TestStatelessEnforcer_Refresh(t *testing.T) {
+	enforcer := newTestAdapterEnforcer(t)
+
+	pair, err := enforcer.LoginWithRefreshToken("1", &model.Login{
+		Device:  "pc",
+		Timeout: 60,
+		JwtData: map[string]interface{}{"k": "v"},
+	}, 3600, nil)
+	if err != nil {
+		t.Fatalf("LoginWithRefreshToken() failed: %v", err)
+	}
+	if id, err := enforcer.GetIdByToken(pair.AccessToken); err != nil || id != "1" {
+		t.Errorf("GetIdByToken() failed: id = %v, err = %v", id, err)
+	}
+	// refresh token can not be used as access token
+	if _, err = enforcer.GetIdByToken(pair.RefreshToken); err == nil {
+		t.Errorf("GetIdByToken() should reject refresh token")
+	}
+	// access token can not be used as refresh token
+	if _, err = enforcer.Refresh(pair.AccessToken); err == nil {
+		t.Errorf("Refresh() should reject access token")
+	}
+
+	newPair, err := enforcer.Refresh(pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() failed: %v", err)
+	}
+	if newPair.AccessTimeout != 60 || newPair.RefreshTimeout <= 0 || newPair.RefreshTimeout > 3600 {
+		t.Errorf("Refresh() failed: unexpected timeout %+v", newPair)
+	}
+	if v, err := enforcer.GetExtraDataByToken(newPair.AccessToken, "k"); err != nil || v != "v" {
+		t.Errorf("GetExtraDataByToken() failed: v = %v, err = %v", v, err)
+	}
+
+	// reuse the old refresh token revokes the family
+	if _, err = enforcer.Refresh(pair.RefreshToken); err == nil {
+		t.Errorf("Refresh() should reject reused refresh token")
+	}
+	if _, err = enforcer.Refresh(newPair.RefreshToken); err == nil {
+		t.Errorf("Refresh() should reject token of revoked family")
+	}
+}
+
+func TestStatelessEnforcer_RefreshExpiredWithinLeeway(t *testing.T) {
+	for _, mode := range []ClaimsMode{LegacyClaims, RegisteredClaims} {
+		enforcer, clock := newTestClockEnforcer(t, mode)
+		enforcer.SetAdapter(persist.NewDefaultAdapter())
+		enforcer.SetLeeway(30 * time.Second)
+
+		pair, err := enforcer.LoginWithRefreshToken("1", &model.Login{Timeout: 60}, 60, nil)
+		if err != nil {
+			t.Fatalf("LoginWithRefreshToken() failed: %v", err)
+		}
+		// refresh token expired 5s ago, still accepted within leeway
+		clock.Add(65 * time.Second)
+		newPair, err := enforcer.Refresh(pair.RefreshToken)
+		if err != nil {
+			t.Fatalf("mode %v Refresh() failed: %v", mode, err)
+		}
+		if newPair.RefreshTimeout != 1 {
+			t.Errorf("mode %v RefreshTimeout = %v, want 1", mode, newPair.RefreshTimeout)
+		}
+		clock.Add(time.Hour)
+		if _, err = enforcer.Refresh(newPair.RefreshToken); err == nil {
+			t.Errorf("mode %v Refresh() should reject expired refresh token", mode)
+		}
+	}
+}
+
+func TestStatelessEnforcer_RevokeRefreshToken(t *testing.T) {
+	enforcer := newTestAdapterEnforcer(t)
+
+	pair, err := enforcer.LoginWithRefreshToken("1", model.DefaultLoginModel(), -1, nil)
+	if err != nil {
+		t.Fatalf("LoginWithRefreshToken() failed: %v", err)
+	}
+	if err = enforcer.RevokeRefreshToken(pair.RefreshToken); err != nil {
+		t.Fatalf("RevokeRefreshToken() failed: %v", err)
+	}
+	if _, err = enforcer.Refresh(pair.RefreshToken); err == nil {
+		t.Errorf("Refresh() should reject revoked refresh token")
+	}
+}
+
+func TestStatelessEnforcer_RefreshWithoutAdapter(t *testing.T) {
+	enforcer := newTestEnforcer(t)
+	enforcer.SetSecretKey("123")
+	if _, err := enforcer.LoginWithRefreshToken("1", model.DefaultLoginModel(), 3600, nil); err == nil {
+		t.Errorf("LoginWithRefreshToken() should fail with EmptyAdapter")
+	}
+}
+
+func TestStatelessEnforcer_RefreshConcurrently(t *testing.T) {
+	enforcer := newTestAdapterEnforcer(t)
+	pair, err := enforcer.LoginWithRefreshToken("1", model.DefaultLoginModel(), 3600, nil)
+	if err != nil {
+		t.Fatalf("LoginWithRefreshToken() failed: %v", err)
+	}
+
+	var mu sync.Mutex
+	var pairs []*TokenPair
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if newPair, err := enforcer.Refresh(pair.RefreshToken); err == nil {
+				mu.Lock()
+				pairs = append(pairs, newPair)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(pairs) != 1 {
+		t.Fatalf("Refresh() succeeded %v times, want 1", len(pairs))
+	}
+	// the other calls are reuse, the whole family is revoked
+	if _, err = enforcer.Refresh(pairs[0].RefreshToken); err == nil {
+		t.Errorf("Refresh() should reject token of revoked family")
+	}
+}
@@ -0,0 +1,206 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var _ keySet = (*RemoteVerifier)(nil)
+
+type RemoteVerifierOptions struct {
+	// Client used to fetch JWKS, default is a client with 10s timeout
+	Client *http.Client
+	// LoginType the login type of token issued by the remote service, default is "user"
+	LoginType string
+	// RefreshInterval cached keys are refetched after RefreshInterval, default is 1 hour
+	RefreshInterval time.Duration
+	// MinRefreshInterval minimum interval between two fetches triggered by unknown kid, default is 1 minute
+	MinRefreshInterval time.Duration
+	// Leeway tolerance of expiration and not-before checks for clock skew
+	Leeway time.Duration
+	// Issuer if not empty, registered layout token must have the same iss
+	Issuer string
+	// Audience if not empty, registered layout token must contain one of them
+	Audience []string
+	// ParserOptions golang-jwt parser and validator options
+	ParserOptions ParserOptions
+}
+
+func initRemoteVerifierOptions(options *RemoteVerifierOptions) {
+	if options.Client == nil {
+		options.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if options.LoginType == "" {
+		options.LoginType = "user"
+	}
+	if options.RefreshInterval <= 0 {
+		options.RefreshInterval = time.Hour
+	}
+	if options.MinRefreshInterval <= 0 {
+		options.MinRefreshInterval = time.Minute
+	}
+}
+
+// RemoteVerifier verify token issued by another service with the keys fetched from its JWKS url.
+// Keys are cached and refreshed every RefreshInterval, an unknown kid triggers a refetch
+type RemoteVerifier struct {
+	url       string
+	options   RemoteVerifierOptions
+	validator *validator
+
+	mu        sync.RWMutex
+	keys      map[string]SigningKey
+	fetchTime time.Time
+	// fetchMu make sure only one fetch at the same time
+	fetchMu sync.Mutex
+}
+
+// NewRemoteVerifier new verifier and fetch JWKS from url
+func NewRemoteVerifier(url string, options RemoteVerifierOptions) (*RemoteVerifier, error) {
+	if url == "" {
+		return nil, errors.New("arg url can not be nil")
+	}
+	initRemoteVerifierOptions(&options)
+	v := &RemoteVerifier{
+		url:     url,
+		options: options,
+		validator: &validator{
+			clock:         time.Now,
+			leeway:        options.Leeway,
+			issuer:        options.Issuer,
+			audience:      options.Audience,
+			parserOptions: options.ParserOptions,
+		},
+		keys: make(map[string]SigningKey),
+	}
+	err := v.Refresh()
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Refresh fetch JWKS and replace cached keys
+func (v *RemoteVerifier) Refresh() error {
+	v.fetchMu.Lock()
+	defer v.fetchMu.Unlock()
+	return v.fetch()
+}
+
+func (v *RemoteVerifier) fetch() error {
+	resp, err := v.options.Client.Get(v.url)
+	if err != nil {
+		return fmt.Errorf("fetch JWKS failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch JWKS failed: unexpected status %v", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("fetch JWKS failed: %v", err)
+	}
+	jwks := &JWKS{}
+	err = json.Unmarshal(body, jwks)
+	if err != nil {
+		return fmt.Errorf("invalid JWKS: %v", err)
+	}
+
+	keys := make(map[string]SigningKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk == nil || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		// skip unsupported keys
+		key, err := jwk.SigningKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.fetchTime = time.Now()
+	v.mu.Unlock()
+	return nil
+}
+
+// lookup find key by kid in cache, if there is only one key, token without kid use it
+func (v *RemoteVerifier) lookup(kid string) (SigningKey, time.Time, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, v.fetchTime, true
+		}
+	}
+	key, ok := v.keys[kid]
+	return key, v.fetchTime, ok
+}
+
+func (v *RemoteVerifier) signingKey() (string, SigningKey, error) {
+	return "", nil, errors.New("RemoteVerifier can not sign token")
+}
+
+func (v *RemoteVerifier) verifyingKey(kid string) (SigningKey, error) {
+	key, fetchTime, ok := v.lookup(kid)
+	if ok && time.Since(fetchTime) < v.options.RefreshInterval {
+		return key, nil
+	}
+
+	// cache expired or unknown kid, refetch
+	v.fetchMu.Lock()
+	// another goroutine may have fetched
+	key, fetchTime, ok = v.lookup(kid)
+	if ok && time.Since(fetchTime) < v.options.RefreshInterval {
+		v.fetchMu.Unlock()
+		return key, nil
+	}
+	var err error
+	if time.Since(fetchTime) >= v.options.MinRefreshInterval {
+		err = v.fetch()
+		key, _, ok = v.lookup(kid)
+	}
+	v.fetchMu.Unlock()
+
+	if ok {
+		// use the stale key if refetch failed
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("unknown JWT kid: %v", kid)
+}
+
+// GetLoginType get the login type of token
+func (v *RemoteVerifier) GetLoginType() string {
+	return v.options.LoginType
+}
+
+// GetIdByToken parse token and get id
+func (v *RemoteVerifier) GetIdByToken(token string) (string, error) {
+	return getIdByKey(token, v.options.LoginType, v, v.validator)
+}
+
+// GetClaimsByToken get token claims
+func (v *RemoteVerifier) GetClaimsByToken(token string) (jwt.Claims, error) {
+	return parseTokenByKey(token, v.options.LoginType, v, true, v.validator)
+}
+
+// GetExtraDataByToken parse extraData map
+func (v *RemoteVerifier) GetExtraDataByToken(token string, key string) (interface{}, error) {
+	return getExtraDataByKey(token, v.options.LoginType, v, key, v.validator)
+}
+
+// GetTokenTimeout parse and get token timeout
+func (v *RemoteVerifier) GetTokenTimeout(token string) (int64, error) {
+	return getTimeoutByKey(token, v.options.LoginType, v, v.validator)
+}
@@ -0,0 +1,98 @@
+package jwt
+
+import (
+	"github.com/weloe/token-go/model"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRemoteVerifier(t *testing.T) {
+	keys := newTestSigningKeys(t)
+	keyRing, err := NewKeyRing("rsa", keys["RS256"][0])
+	if err != nil {
+		t.Fatalf("NewKeyRing() failed: %v", err)
+	}
+	issuer, err := NewEnforcerWithKeyRing(keyRing)
+	if err != nil {
+		t.Fatalf("NewEnforcerWithKeyRing() failed: %v", err)
+	}
+
+	var fetchCount int32
+	jwksHandler := NewJWKSHandler(issuer)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetchCount, 1)
+		jwksHandler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	verifier, err := NewRemoteVerifier(server.URL+JWKS_PATH, RemoteVerifierOptions{
+		Client:             server.Client(),
+		MinRefreshInterval: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatalf("NewRemoteVerifier() failed: %v", err)
+	}
+
+	token, err := issuer.LoginByModel("1", &model.Login{
+		Device:  "pc",
+		Timeout: 60,
+		JwtData: map[string]interface{}{"k": "v"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if id, err := verifier.GetIdByToken(token); err != nil || id != "1" {
+			t.Errorf("GetIdByToken() failed: id = %v, err = %v", id, err)
+		}
+	}
+	if n := atomic.LoadInt32(&fetchCount); n != 1 {
+		t.Errorf("keys should be cached: fetch count = %v", n)
+	}
+	if v, err := verifier.GetExtraDataByToken(token, "k"); err != nil || v != "v" {
+		t.Errorf("GetExtraDataByToken() failed: v = %v, err = %v", v, err)
+	}
+	if timeout, err := verifier.GetTokenTimeout(token); err != nil || timeout <= 0 {
+		t.Errorf("GetTokenTimeout() failed: timeout = %v, err = %v", timeout, err)
+	}
+	if _, err = verifier.GetClaimsByToken(token); err != nil {
+		t.Errorf("GetClaimsByToken() failed: %v", err)
+	}
+
+	// unknown kid triggers refetch
+	err = keyRing.Rotate("ec", keys["ES256"][0], -1)
+	if err != nil {
+		t.Fatalf("Rotate() failed: %v", err)
+	}
+	token, err = issuer.Login("2", nil)
+	if err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+	if id, err := verifier.GetIdByToken(token); err != nil || id != "2" {
+		t.Errorf("GetIdByToken() after rotate failed: id = %v, err = %v", id, err)
+	}
+	if n := atomic.LoadInt32(&fetchCount); n != 2 {
+		t.Errorf("unknown kid should refetch: fetch count = %v", n)
+	}
+
+	// token signed by a key which is not in JWKS
+	other, _ := NewEnforcerWithKey(keys["EdDSA"][0])
+	token, err = other.Login("3", nil)
+	if err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+	if _, err = verifier.GetIdByToken(token); err == nil {
+		t.Errorf("GetIdByToken() should reject token signed by unknown key")
+	}
+}
+
+func TestNewRemoteVerifier_Failed(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	if _, err := NewRemoteVerifier(server.URL, RemoteVerifierOptions{Client: server.Client()}); err == nil {
+		t.Errorf("NewRemoteVerifier() should fail when JWKS is not found")
+	}
+}
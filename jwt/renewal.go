@@ -0,0 +1,182 @@
+package jwt
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/weloe/token-go/ctx"
+	"github.com/weloe/token-go/model"
+	"github.com/weloe/token-go/util"
+	"time"
+)
+
+// AUTH_TIME login time in seconds, kept when token is renewed
+const AUTH_TIME = "auth_time"
+
+// RenewalOptions sliding renewal policy, a token whose remaining life is less than Threshold
+// is reissued with the same claims and responded to ctx.Context
+type RenewalOptions struct {
+	// Threshold seconds, renew token if its remaining life is less than Threshold, renewal is disabled if <= 0
+	Threshold int64
+	// Timeout seconds, timeout of renewed token, default is TokenConfig.Timeout
+	Timeout int64
+	// MaxLifetime seconds, renewed token can not expire later than login time + MaxLifetime, unlimited if <= 0
+	MaxLifetime int64
+	// IsWriteHeader write renewed token to response header, cookie is written if TokenConfig.IsReadCookie is true
+	IsWriteHeader bool
+	// IsLastingCookie if false, cookie of renewed token expires when browser is closed
+	IsLastingCookie bool
+}
+
+// SetRenewalOptions set sliding renewal policy, token of request is renewed by GetLoginId, GetClaims and GetExtraData
+func (s *StatelessEnforcer) SetRenewalOptions(options RenewalOptions) {
+	s.renewalOptions = options
+}
+
+// GetRenewalOptions get sliding renewal policy
+func (s *StatelessEnforcer) GetRenewalOptions() RenewalOptions {
+	return s.renewalOptions
+}
+
+// parseRequestToken parse the token of request, verify DPoP proof if token is bound to a key,
+// and renew it if renewal is enabled
+func (s *StatelessEnforcer) parseRequestToken(ctx ctx.Context, token string) (jwt.MapClaims, error) {
+	payloads, err := s.verifyRequestToken(ctx, token, true)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.renewToken(ctx, payloads)
+	if err != nil {
+		return nil, err
+	}
+	return payloads, nil
+}
+
+// verifyRequestToken parse the token of request and verify DPoP proof if token is bound to a key
+func (s *StatelessEnforcer) verifyRequestToken(ctx ctx.Context, token string, isCheckTimeout bool) (jwt.MapClaims, error) {
+	payloads, err := s.parseToken(token, isCheckTimeout)
+	if err != nil {
+		return nil, err
+	}
+	err = s.checkProof(ctx, token, payloads)
+	if err != nil {
+		return nil, err
+	}
+	return payloads, nil
+}
+
+// renewToken reissue token and respond it if its remaining life is less than threshold,
+// return empty string if token is not renewed
+func (s *StatelessEnforcer) renewToken(ctx ctx.Context, payloads jwt.MapClaims) (string, error) {
+	options := s.renewalOptions
+	if ctx == nil || options.Threshold <= 0 {
+		return "", nil
+	}
+	// derived token of Exchange expires as it is
+	if _, ok := payloads[ACTOR]; ok {
+		return "", nil
+	}
+	now := s.validator.now()
+	exp, ok := claimExpiration(payloads)
+	if !ok || exp <= NEVER_EXPIRE || exp-now.UnixMilli() >= options.Threshold*1000 {
+		return "", nil
+	}
+
+	authTime, hasAuthTime := loginTime(payloads)
+	if !hasAuthTime && options.MaxLifetime > 0 {
+		// login time is unknown, such as legacy token issued before MaxLifetime is set, it expires as it is
+		return "", nil
+	}
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = s.GetTokenConfig().Timeout
+	}
+	if options.MaxLifetime > 0 {
+		if remain := authTime + options.MaxLifetime - now.Unix(); remain < timeout {
+			timeout = remain
+		}
+		// max lifetime reached, token expires as it is
+		if now.UnixMilli()+timeout*1000 <= exp {
+			return "", nil
+		}
+	}
+
+	claims, err := renewClaims(payloads, authTime, hasAuthTime, timeout, now)
+	if err != nil {
+		return "", err
+	}
+	token, err := s.generateToken(claims)
+	if err != nil {
+		return "", err
+	}
+
+	device, _ := payloads[DEVICE].(string)
+	loginModel := &model.Login{
+		Device:          device,
+		IsLastingCookie: options.IsLastingCookie,
+		Timeout:         timeout,
+		IsWriteHeader:   options.IsWriteHeader,
+	}
+	if s.stateful {
+		id, _ := claimLoginId(payloads)
+		_, err = s.loginSession(id, token, loginModel, ctx)
+	} else {
+		err = s.e.ResponseToken(token, loginModel, ctx)
+	}
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// renewClaims copy claims with new jti and expiration time, keep login time if it is known
+func renewClaims(payloads jwt.MapClaims, authTime int64, hasAuthTime bool, timeout int64, now time.Time) (jwt.MapClaims, error) {
+	claims, err := reissueClaims(payloads, timeout, now)
+	if err != nil {
+		return nil, err
+	}
+	if hasAuthTime {
+		claims[AUTH_TIME] = authTime
+	}
+	return claims, nil
+}
+
+// loginTime return auth_time of token, or iat if token has never been renewed
+func loginTime(payloads jwt.MapClaims) (int64, bool) {
+	if t, ok := payloads[AUTH_TIME].(float64); ok {
+		return int64(t), true
+	}
+	// renewed token always carries auth_time if its login time is known
+	if t, ok := payloads[ISSUED_AT].(float64); ok && isRegisteredLayout(payloads) {
+		return int64(t), true
+	}
+	return 0, false
+}
+
+// reissueClaims copy claims with new jti and expiration time
+func reissueClaims(payloads jwt.MapClaims, timeout int64, now time.Time) (jwt.MapClaims, error) {
+	randomString32, err := util.GenerateRandomString32()
+	if err != nil {
+		return nil, err
+	}
+	claims := make(jwt.MapClaims, len(payloads)+1)
+	for k, v := range payloads {
+		claims[k] = v
+	}
+	claims[JTI] = randomString32
+	if isRegisteredLayout(claims) {
+		claims[ISSUED_AT] = now.Unix()
+		claims[NOT_BEFORE] = now.Unix()
+		if timeout > NEVER_EXPIRE {
+			claims[EXPIRES_AT] = now.Unix() + timeout
+		} else {
+			delete(claims, EXPIRES_AT)
+		}
+	} else {
+		claims[RANDOM] = randomString32
+		if timeout > NEVER_EXPIRE {
+			claims[EFF] = now.UnixMilli() + timeout*1000
+		} else {
+			claims[EFF] = timeout
+		}
+	}
+	return claims, nil
+}
@@ -0,0 +1,135 @@
+package jwt
+
+import (
+	tokenGo "github.com/weloe/token-go"
+	"github.com/weloe/token-go/ctx"
+	"github.com/weloe/token-go/model"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestRequestContext(t *testing.T, enforcer IEnforcer, token string) (ctx.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(enforcer.GetTokenConfig().TokenName, token)
+	recorder := httptest.NewRecorder()
+	return tokenGo.NewHttpContext(req, recorder), recorder
+}
+
+func TestStatelessEnforcer_Renewal(t *testing.T) {
+	for _, mode := range []ClaimsMode{LegacyClaims, RegisteredClaims} {
+		enforcer, clock := newTestClockEnforcer(t, mode)
+		enforcer.SetRenewalOptions(RenewalOptions{Threshold: 30, Timeout: 60, MaxLifetime: 120, IsWriteHeader: true})
+		tokenName := enforcer.GetTokenConfig().TokenName
+
+		token, err := enforcer.LoginByModel("1", &model.Login{Timeout: 60, JwtData: map[string]interface{}{"name": "weloe"}}, nil)
+		if err != nil {
+			t.Fatalf("LoginByModel() failed: %v", err)
+		}
+
+		// remaining life 50s, not renewed
+		clock.Add(10 * time.Second)
+		c, recorder := newTestRequestContext(t, enforcer, token)
+		if id, err := enforcer.GetLoginId(c); err != nil || id != "1" {
+			t.Fatalf("mode %v GetLoginId() failed: id = %v, err = %v", mode, id, err)
+		}
+		if renewed := recorder.Header().Get(tokenName); renewed != "" {
+			t.Errorf("mode %v token should not be renewed", mode)
+		}
+
+		// remaining life 20s, renewed with 60s
+		clock.Add(30 * time.Second)
+		c, recorder = newTestRequestContext(t, enforcer, token)
+		if _, err = enforcer.GetLoginId(c); err != nil {
+			t.Fatalf("mode %v GetLoginId() failed: %v", mode, err)
+		}
+		renewed := recorder.Header().Get(tokenName)
+		if renewed == "" || renewed == token {
+			t.Fatalf("mode %v token should be renewed", mode)
+		}
+		if timeout, err := enforcer.GetTokenTimeout(renewed); err != nil || timeout != 60 {
+			t.Errorf("mode %v GetTokenTimeout() renewed failed: timeout = %v, err = %v", mode, timeout, err)
+		}
+		if data, err := enforcer.GetExtraDataByToken(renewed, "name"); err != nil || data != "weloe" {
+			t.Errorf("mode %v renewed token should keep claims: data = %v, err = %v", mode, data, err)
+		}
+
+		// 90s after login, renewed token is limited by max lifetime 120s
+		clock.Add(50 * time.Second)
+		c, recorder = newTestRequestContext(t, enforcer, renewed)
+		if _, err = enforcer.GetClaims(c); err != nil {
+			t.Fatalf("mode %v GetClaims() failed: %v", mode, err)
+		}
+		last := recorder.Header().Get(tokenName)
+		if timeout, err := enforcer.GetTokenTimeout(last); err != nil || timeout != 30 {
+			t.Errorf("mode %v GetTokenTimeout() last failed: timeout = %v, err = %v", mode, timeout, err)
+		}
+
+		// max lifetime reached, token is not renewed
+		clock.Add(25 * time.Second)
+		c, recorder = newTestRequestContext(t, enforcer, last)
+		if _, err = enforcer.GetExtraData(c, "name"); err != nil {
+			t.Fatalf("mode %v GetExtraData() failed: %v", mode, err)
+		}
+		if recorder.Header().Get(tokenName) != "" {
+			t.Errorf("mode %v token should not be renewed after max lifetime", mode)
+		}
+	}
+}
+
+func TestStatelessEnforcer_RenewalWithoutAuthTime(t *testing.T) {
+	for _, mode := range []ClaimsMode{LegacyClaims, RegisteredClaims} {
+		enforcer, clock := newTestClockEnforcer(t, mode)
+		tokenName := enforcer.GetTokenConfig().TokenName
+		// token issued before MaxLifetime is set has no auth_time
+		token, err := enforcer.LoginByModel("1", &model.Login{Timeout: 60}, nil)
+		if err != nil {
+			t.Fatalf("LoginByModel() failed: %v", err)
+		}
+		enforcer.SetRenewalOptions(RenewalOptions{Threshold: 30, Timeout: 60, MaxLifetime: 100, IsWriteHeader: true})
+
+		clock.Add(50 * time.Second)
+		c, recorder := newTestRequestContext(t, enforcer, token)
+		if _, err = enforcer.GetLoginId(c); err != nil {
+			t.Fatalf("mode %v GetLoginId() failed: %v", mode, err)
+		}
+		renewed := recorder.Header().Get(tokenName)
+		if mode == LegacyClaims {
+			// login time is unknown, not extended
+			if renewed != "" {
+				t.Errorf("mode %v token without login time should not be renewed", mode)
+			}
+			continue
+		}
+		// max lifetime is measured from iat
+		if timeout, err := enforcer.GetTokenTimeout(renewed); err != nil || timeout != 50 {
+			t.Fatalf("mode %v GetTokenTimeout() renewed = %v, err = %v, want 50", mode, timeout, err)
+		}
+		clock.Add(40 * time.Second)
+		c, recorder = newTestRequestContext(t, enforcer, renewed)
+		if _, err = enforcer.GetLoginId(c); err != nil {
+			t.Fatalf("mode %v GetLoginId() failed: %v", mode, err)
+		}
+		if recorder.Header().Get(tokenName) != "" {
+			t.Errorf("mode %v token should not be renewed after max lifetime", mode)
+		}
+	}
+}
+
+func TestMixedEnforcer_Renewal(t *testing.T) {
+	enforcer := newTestMixedEnforcer(t)
+	enforcer.SetRenewalOptions(RenewalOptions{Threshold: 120, Timeout: 600, IsWriteHeader: true})
+
+	token, err := enforcer.LoginByModel("1", &model.Login{Device: "pc", Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	c, recorder := newTestRequestContext(t, enforcer, token)
+	if _, err = enforcer.GetLoginId(c); err != nil {
+		t.Fatalf("GetLoginId() failed: %v", err)
+	}
+	renewed := recorder.Header().Get(enforcer.GetTokenConfig().TokenName)
+	if id, err := enforcer.GetIdByToken(renewed); err != nil || id != "1" {
+		t.Errorf("renewed token should be registered in session: id = %v, err = %v", id, err)
+	}
+}
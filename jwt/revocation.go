@@ -0,0 +1,157 @@
+package jwt
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/weloe/token-go/constant"
+	"github.com/weloe/token-go/ctx"
+	tokenErrors "github.com/weloe/token-go/errors"
+	"math"
+	"strconv"
+)
+
+// BeRevoked revoked token mark, such as logout or RevokeToken
+const BeRevoked = "revoked"
+
+// Logout revoke the token of request and delete cookie
+func (s *StatelessEnforcer) Logout(ctx ctx.Context) error {
+	tokenConfig := s.GetTokenConfig()
+
+	token := s.GetRequestToken(ctx)
+	if token == "" {
+		return errors.New("logout() failed: token doesn't exist")
+	}
+	err := s.checkRequestProof(ctx)
+	if err != nil {
+		return err
+	}
+	if tokenConfig.IsReadCookie {
+		ctx.Response().DeleteCookie(tokenConfig.TokenName,
+			tokenConfig.CookieConfig.Path,
+			tokenConfig.CookieConfig.Domain)
+	}
+
+	return s.LogoutByToken(token)
+}
+
+// checkRequestProof verify DPoP proof of the request token, expired token is accepted
+func (s *StatelessEnforcer) checkRequestProof(ctx ctx.Context) error {
+	token := s.GetRequestToken(ctx)
+	payloads, err := s.parseTokenByKey(token, s.GetType(), false)
+	if err != nil {
+		return err
+	}
+	return s.checkProof(ctx, token, payloads)
+}
+
+// LogoutByToken revoke token
+func (s *StatelessEnforcer) LogoutByToken(token string) error {
+	id, err := s.revoke(token, BeRevoked)
+	if err != nil {
+		return err
+	}
+
+	s.e.GetLogger().Logout(s.GetType(), id, token)
+
+	if s.e.GetWatcher() != nil {
+		s.e.GetWatcher().Logout(s.GetType(), id, token)
+	}
+	return nil
+}
+
+// Kickout mark token kicked, parsing it returns errors.BeKicked
+func (s *StatelessEnforcer) Kickout(token string) error {
+	id, err := s.revoke(token, strconv.Itoa(constant.BeKicked))
+	if err != nil {
+		return err
+	}
+
+	s.e.GetLogger().Kickout(s.GetType(), id, token)
+
+	if s.e.GetWatcher() != nil {
+		s.e.GetWatcher().Kickout(s.GetType(), id, token)
+	}
+	return nil
+}
+
+// RevokeToken record the jti of token in adapter until the token expires
+func (s *StatelessEnforcer) RevokeToken(token string) error {
+	_, err := s.revoke(token, BeRevoked)
+	return err
+}
+
+// IsRevoked check if the token is revoked
+func (s *StatelessEnforcer) IsRevoked(token string) bool {
+	payloads, err := s.parseTokenByKey(token, s.GetType(), false)
+	if err != nil {
+		return false
+	}
+	return s.checkRevoked(payloads) != nil
+}
+
+// revoke record jti with mark, the record expires when token expires, return loginId
+func (s *StatelessEnforcer) revoke(token string, mark string) (string, error) {
+	if !s.isAdapterEnabled() {
+		return "", errors.New("revoke token needs a persist.Adapter, please call SetAdapter()")
+	}
+	payloads, err := s.parseTokenByKey(token, s.GetType(), false)
+	if err != nil {
+		return "", err
+	}
+	id, err := getIdFromClaims(token, payloads)
+	if err != nil {
+		return "", err
+	}
+	jti := getJti(payloads)
+	if jti == "" {
+		return "", newTokenError(ErrInvalidClaims, token, errors.New("invalid jti"))
+	}
+
+	timeout, err := calTimeout(token, payloads, s.validator.now())
+	if err != nil {
+		return "", err
+	}
+	if timeout == NOT_VALUE_EXPIRE {
+		// token has expired, no need to record
+		return id, nil
+	}
+	if timeout != NEVER_EXPIRE {
+		// expired token is accepted within leeway
+		timeout += int64(math.Ceil(s.GetLeeway().Seconds()))
+	}
+	if timeout == 0 {
+		// less than one second left
+		timeout = 1
+	}
+
+	err = s.GetAdapter().SetStr(s.spliceRevokedKey(jti), mark, timeout)
+	if err != nil {
+		return "", err
+	}
+	s.tokenCache.remove(token)
+	return id, nil
+}
+
+// checkRevoked return error if the jti is revoked, skip if adapter is persist.EmptyAdapter
+func (s *StatelessEnforcer) checkRevoked(payloads jwt.MapClaims) error {
+	if !s.isAdapterEnabled() {
+		return nil
+	}
+	jti := getJti(payloads)
+	if jti == "" {
+		return nil
+	}
+	switch s.GetAdapter().GetStr(s.spliceRevokedKey(jti)) {
+	case "":
+		return nil
+	case strconv.Itoa(constant.BeKicked):
+		return tokenErrors.BeKicked
+	default:
+		return ErrRevoked
+	}
+}
+
+// spliceRevokedKey splice revoked jti key
+func (s *StatelessEnforcer) spliceRevokedKey(jti string) string {
+	return s.GetTokenConfig().TokenName + ":" + s.GetType() + ":revoked:" + jti
+}
@@ -0,0 +1,83 @@
+package jwt
+
+import (
+	"errors"
+	tokenErrors "github.com/weloe/token-go/errors"
+	"github.com/weloe/token-go/model"
+	"testing"
+)
+
+func TestStatelessEnforcer_RevokeToken(t *testing.T) {
+	enforcer := newTestAdapterEnforcer(t)
+
+	token, err := enforcer.Login("1", nil)
+	if err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+	other, err := enforcer.Login("1", nil)
+	if err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+	if enforcer.IsRevoked(token) {
+		t.Errorf("IsRevoked() failed: token should not be revoked")
+	}
+
+	if err = enforcer.LogoutByToken(token); err != nil {
+		t.Fatalf("LogoutByToken() failed: %v", err)
+	}
+	if !enforcer.IsRevoked(token) {
+		t.Errorf("IsRevoked() failed: token should be revoked")
+	}
+	if _, err = enforcer.GetIdByToken(token); err == nil {
+		t.Errorf("GetIdByToken() should reject revoked token")
+	}
+	if _, err = enforcer.GetTokenTimeout(token); err == nil {
+		t.Errorf("GetTokenTimeout() should reject revoked token")
+	}
+	if _, err = enforcer.GetExtraDataByToken(token, "k"); err == nil {
+		t.Errorf("GetExtraDataByToken() should reject revoked token")
+	}
+	// other token is not affected
+	if id, err := enforcer.GetIdByToken(other); err != nil || id != "1" {
+		t.Errorf("GetIdByToken() failed: id = %v, err = %v", id, err)
+	}
+
+	if err = enforcer.Kickout(other); err != nil {
+		t.Fatalf("Kickout() failed: %v", err)
+	}
+	if _, err = enforcer.GetIdByToken(other); !errors.Is(err, tokenErrors.BeKicked) {
+		t.Errorf("GetIdByToken() failed: unexpected err %v", err)
+	}
+}
+
+func TestStatelessEnforcer_RevokeNeverExpireToken(t *testing.T) {
+	enforcer := newTestAdapterEnforcer(t)
+
+	token, err := enforcer.LoginByModel("1", &model.Login{Timeout: NEVER_EXPIRE}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	if err = enforcer.RevokeToken(token); err != nil {
+		t.Fatalf("RevokeToken() failed: %v", err)
+	}
+	if _, err = enforcer.GetIdByToken(token); err == nil {
+		t.Errorf("GetIdByToken() should reject revoked token")
+	}
+}
+
+func TestStatelessEnforcer_RevokeWithoutAdapter(t *testing.T) {
+	enforcer := newTestEnforcer(t)
+	enforcer.SetSecretKey("123")
+
+	token, err := enforcer.Login("1", nil)
+	if err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+	if err = enforcer.RevokeToken(token); err == nil {
+		t.Errorf("RevokeToken() should fail with EmptyAdapter")
+	}
+	// parse does not touch EmptyAdapter
+	if _, err = enforcer.GetIdByToken(token); err != nil {
+		t.Errorf("GetIdByToken() failed: %v", err)
+	}
+}
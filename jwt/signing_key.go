@@ -0,0 +1,143 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var _ SigningKey = (*signingKey)(nil)
+
+// SigningKey used to sign and verify JWT token
+type SigningKey interface {
+	// Method return the signing method, such as HS256, RS256, ES256, EdDSA
+	Method() jwt.SigningMethod
+	// SignKey return the key used to sign token, return nil if the key can only verify token
+	SignKey() interface{}
+	// VerifyKey return the key used to verify token
+	VerifyKey() interface{}
+}
+
+type signingKey struct {
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+func (k *signingKey) Method() jwt.SigningMethod {
+	return k.method
+}
+
+func (k *signingKey) SignKey() interface{} {
+	return k.signKey
+}
+
+func (k *signingKey) VerifyKey() interface{} {
+	return k.verifyKey
+}
+
+// NewHMACKey HS256 key, use the same secret to sign and verify
+func NewHMACKey(secret string) SigningKey {
+	return &signingKey{
+		method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+}
+
+// NewRSAKey RS256 key, sign with privateKey and verify with its public key
+func NewRSAKey(privateKey *rsa.PrivateKey) (SigningKey, error) {
+	if privateKey == nil {
+		return nil, errors.New("rsa private key can not be nil")
+	}
+	return &signingKey{
+		method:    jwt.SigningMethodRS256,
+		signKey:   privateKey,
+		verifyKey: &privateKey.PublicKey,
+	}, nil
+}
+
+// NewRSAPublicKey RS256 key, can only verify token
+func NewRSAPublicKey(publicKey *rsa.PublicKey) (SigningKey, error) {
+	if publicKey == nil {
+		return nil, errors.New("rsa public key can not be nil")
+	}
+	return &signingKey{
+		method:    jwt.SigningMethodRS256,
+		verifyKey: publicKey,
+	}, nil
+}
+
+// NewECDSAKey ES256 | ES384 | ES512 key, signing method depends on the curve of privateKey
+func NewECDSAKey(privateKey *ecdsa.PrivateKey) (SigningKey, error) {
+	if privateKey == nil {
+		return nil, errors.New("ecdsa private key can not be nil")
+	}
+	method, err := ecdsaMethod(privateKey.Curve)
+	if err != nil {
+		return nil, err
+	}
+	return &signingKey{
+		method:    method,
+		signKey:   privateKey,
+		verifyKey: &privateKey.PublicKey,
+	}, nil
+}
+
+// NewECDSAPublicKey ES256 | ES384 | ES512 key, can only verify token
+func NewECDSAPublicKey(publicKey *ecdsa.PublicKey) (SigningKey, error) {
+	if publicKey == nil {
+		return nil, errors.New("ecdsa public key can not be nil")
+	}
+	method, err := ecdsaMethod(publicKey.Curve)
+	if err != nil {
+		return nil, err
+	}
+	return &signingKey{
+		method:    method,
+		verifyKey: publicKey,
+	}, nil
+}
+
+// NewEd25519Key EdDSA key, sign with privateKey and verify with its public key
+func NewEd25519Key(privateKey ed25519.PrivateKey) (SigningKey, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid ed25519 private key")
+	}
+	return &signingKey{
+		method:    jwt.SigningMethodEdDSA,
+		signKey:   privateKey,
+		verifyKey: privateKey.Public().(ed25519.PublicKey),
+	}, nil
+}
+
+// NewEd25519PublicKey EdDSA key, can only verify token
+func NewEd25519PublicKey(publicKey ed25519.PublicKey) (SigningKey, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid ed25519 public key")
+	}
+	return &signingKey{
+		method:    jwt.SigningMethodEdDSA,
+		verifyKey: publicKey,
+	}, nil
+}
+
+func ecdsaMethod(curve elliptic.Curve) (jwt.SigningMethod, error) {
+	if curve == nil {
+		return nil, errors.New("ecdsa curve can not be nil")
+	}
+	switch curve.Params().Name {
+	case elliptic.P256().Params().Name:
+		return jwt.SigningMethodES256, nil
+	case elliptic.P384().Params().Name:
+		return jwt.SigningMethodES384, nil
+	case elliptic.P521().Params().Name:
+		return jwt.SigningMethodES512, nil
+	default:
+		return nil, fmt.Errorf("unsupported ecdsa curve: %v", curve.Params().Name)
+	}
+}
@@ -0,0 +1,104 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+)
+
+func newTestSigningKeys(t *testing.T) map[string][2]SigningKey {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() failed: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() failed: %v", err)
+	}
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey() failed: %v", err)
+	}
+
+	keys := make(map[string][2]SigningKey)
+	rsaPriv, _ := NewRSAKey(rsaKey)
+	rsaPub, _ := NewRSAPublicKey(&rsaKey.PublicKey)
+	keys["RS256"] = [2]SigningKey{rsaPriv, rsaPub}
+	ecPriv, _ := NewECDSAKey(ecKey)
+	ecPub, _ := NewECDSAPublicKey(&ecKey.PublicKey)
+	keys["ES256"] = [2]SigningKey{ecPriv, ecPub}
+	edPrivKey, _ := NewEd25519Key(edPriv)
+	edPubKey, _ := NewEd25519PublicKey(edPub)
+	keys["EdDSA"] = [2]SigningKey{edPrivKey, edPubKey}
+	return keys
+}
+
+func TestSigningKey_Asymmetric(t *testing.T) {
+	for alg, pair := range newTestSigningKeys(t) {
+		if pair[0].Method().Alg() != alg {
+			t.Errorf("Method() failed: unexpected alg %v, want %v", pair[0].Method().Alg(), alg)
+		}
+
+		signer, err := NewEnforcerWithKey(pair[0])
+		if err != nil {
+			t.Fatalf("NewEnforcerWithKey() failed: %v", err)
+		}
+		token, err := signer.Login("1", nil)
+		if err != nil {
+			t.Fatalf("%v Login() failed: %v", alg, err)
+		}
+
+		verifier, err := NewEnforcerWithKey(pair[1])
+		if err != nil {
+			t.Fatalf("NewEnforcerWithKey() failed: %v", err)
+		}
+		id, err := verifier.GetIdByToken(token)
+		if err != nil {
+			t.Errorf("%v GetIdByToken() failed: %v", alg, err)
+		}
+		if id != "1" {
+			t.Errorf("%v GetIdByToken() failed: unexpected id %v", alg, id)
+		}
+		if _, err = verifier.GetTokenTimeout(token); err != nil {
+			t.Errorf("%v GetTokenTimeout() failed: %v", alg, err)
+		}
+
+		// public key can not sign
+		if _, err = verifier.Login("1", nil); err == nil {
+			t.Errorf("%v Login() with public key should fail", alg)
+		}
+	}
+}
+
+func TestSigningKey_AlgorithmMismatch(t *testing.T) {
+	keys := newTestSigningKeys(t)
+	signer, _ := NewEnforcerWithKey(keys["RS256"][0])
+	token, err := signer.Login("1", nil)
+	if err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+
+	hmacEnforcer := newTestEnforcer(t)
+	hmacEnforcer.SetSecretKey("123")
+	if _, err = hmacEnforcer.GetIdByToken(token); err == nil {
+		t.Errorf("GetIdByToken() should reject RS256 token with HS256 key")
+	}
+
+	esEnforcer, _ := NewEnforcerWithKey(keys["ES256"][1])
+	if _, err = esEnforcer.GetIdByToken(token); err == nil {
+		t.Errorf("GetIdByToken() should reject RS256 token with ES256 key")
+	}
+}
+
+func TestNewECDSAKey_UnsupportedCurve(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() failed: %v", err)
+	}
+	if _, err = NewECDSAKey(ecKey); err == nil {
+		t.Errorf("NewECDSAKey() should reject P-224 curve")
+	}
+}
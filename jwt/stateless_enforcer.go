@@ -2,31 +2,41 @@ package jwt
 
 import (
 	"errors"
-	"fmt"
-	"github.com/golang-jwt/jwt"
+	"github.com/golang-jwt/jwt/v5"
 	tokenGo "github.com/weloe/token-go"
 	"github.com/weloe/token-go/config"
 	"github.com/weloe/token-go/ctx"
 	"github.com/weloe/token-go/log"
 	"github.com/weloe/token-go/model"
 	"github.com/weloe/token-go/persist"
+	"time"
 )
 
 // StatelessEnforcer use Jwt implement
 type StatelessEnforcer struct {
-	e *tokenGo.Enforcer
-}
-
-func (s *StatelessEnforcer) SetAuth(manager interface{}) {
-	s.e.SetAuth(manager)
-}
-
-func (s *StatelessEnforcer) CheckRole(ctx ctx.Context, role string) error {
-	return s.e.CheckRole(ctx, role)
-}
-
-func (s *StatelessEnforcer) CheckPermission(ctx ctx.Context, permission string) error {
-	return s.e.CheckPermission(ctx, permission)
+	e             *tokenGo.Enforcer
+	signingKey    SigningKey
+	keyRing       *KeyRing
+	claimsOptions ClaimsOptions
+	validator     *validator
+	// renewalOptions sliding renewal policy
+	renewalOptions RenewalOptions
+	// versionCache local cache of token versions
+	versionCache *versionCache
+	// authManager roles, permissions and scopes are embedded in token by authManager
+	authManager interface{}
+	// stateful token is also registered in token-go session, see MixedEnforcer
+	stateful bool
+	// encryptionKey enable JWE mode if not nil
+	encryptionKey EncryptionKey
+	// tokenCache verified token cache, nil if disabled
+	tokenCache *tokenCache
+	// keyProvider pull the current key on every sign and verify
+	keyProvider KeyProvider
+	// dpopOptions DPoP proof verification options
+	dpopOptions DPoPOptions
+	// qrCodeOptions QR code login options
+	qrCodeOptions QRCodeOptions
 }
 
 func (s *StatelessEnforcer) GetAdapter() persist.Adapter {
@@ -61,7 +71,39 @@ func NewEnforcer(args ...interface{}) (*StatelessEnforcer, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &StatelessEnforcer{e}, nil
+	return &StatelessEnforcer{
+		e:            e,
+		validator:    &validator{clock: time.Now},
+		versionCache: newVersionCache(defaultVersionCacheTimeout),
+	}, nil
+}
+
+// NewEnforcerWithKey new jwt enforcer which sign and verify token with SigningKey,
+// other parameter need TokenConfig or string
+func NewEnforcerWithKey(key SigningKey, args ...interface{}) (*StatelessEnforcer, error) {
+	if key == nil {
+		return nil, errors.New("arg key can not be nil")
+	}
+	s, err := NewEnforcer(args...)
+	if err != nil {
+		return nil, err
+	}
+	s.SetSigningKey(key)
+	return s, nil
+}
+
+// NewEnforcerWithKeyRing new jwt enforcer which sign token with the active key of KeyRing
+// and verify token with the key selected by kid, other parameter need TokenConfig or string
+func NewEnforcerWithKeyRing(keyRing *KeyRing, args ...interface{}) (*StatelessEnforcer, error) {
+	if keyRing == nil {
+		return nil, errors.New("arg keyRing can not be nil")
+	}
+	s, err := NewEnforcer(args...)
+	if err != nil {
+		return nil, err
+	}
+	s.SetKeyRing(keyRing)
+	return s, nil
 }
 
 func (s *StatelessEnforcer) SetType(t string) {
@@ -90,12 +132,73 @@ func (s *StatelessEnforcer) GetWatcher() persist.Watcher {
 
 func (s *StatelessEnforcer) SetSecretKey(secret string) {
 	s.e.SetJwtSecretKey(secret)
+	s.tokenCache.clear()
 }
 
 func (s *StatelessEnforcer) GetSecretKey() string {
 	return s.e.GetTokenConfig().JwtSecretKey
 }
 
+// SetSigningKey set the key used to sign and verify token, if key is nil, use HS256 with secret key
+func (s *StatelessEnforcer) SetSigningKey(key SigningKey) {
+	s.signingKey = key
+	s.tokenCache.clear()
+}
+
+// GetSigningKey get the key used to sign and verify token, default is HS256 with secret key.
+// If KeyRing is set, return the active key of KeyRing.
+// If KeyProvider is set, return the current key of KeyProvider, return nil if provider fails
+func (s *StatelessEnforcer) GetSigningKey() SigningKey {
+	if s.keyRing != nil {
+		_, key := s.keyRing.ActiveKey()
+		return key
+	}
+	if s.keyProvider != nil {
+		key, _ := s.keyProvider.GetKey()
+		return key
+	}
+	if s.signingKey != nil {
+		return s.signingKey
+	}
+	return NewHMACKey(s.GetSecretKey())
+}
+
+// SetKeyRing set the KeyRing used to sign and verify token, it takes precedence over SigningKey.
+// Token is signed with the active key and verified with the key selected by kid header
+func (s *StatelessEnforcer) SetKeyRing(keyRing *KeyRing) {
+	s.keyRing = keyRing
+	s.tokenCache.clear()
+}
+
+// GetKeyRing get the KeyRing, return nil if not set
+func (s *StatelessEnforcer) GetKeyRing() *KeyRing {
+	return s.keyRing
+}
+
+// SetClaimsOptions set the claims layout of created token and the registered claims verification
+func (s *StatelessEnforcer) SetClaimsOptions(options ClaimsOptions) {
+	s.claimsOptions = options
+	s.validator.issuer = options.Issuer
+	s.validator.audience = options.Audience
+	s.validator.allowLegacy = options.AllowLegacy
+	s.tokenCache.clear()
+}
+
+// GetClaimsOptions get the claims options
+func (s *StatelessEnforcer) GetClaimsOptions() ClaimsOptions {
+	return s.claimsOptions
+}
+
+func (s *StatelessEnforcer) keys() keySet {
+	if s.keyRing != nil {
+		return s.keyRing
+	}
+	if s.keyProvider != nil {
+		return &providerKeySet{s.keyProvider}
+	}
+	return &staticKeySet{s.GetSigningKey()}
+}
+
 // Login loginById and loginModel, return tokenValue and error
 // ctx.Context can be nil
 func (s *StatelessEnforcer) Login(id string, ctx ctx.Context) (string, error) {
@@ -108,12 +211,20 @@ func (s *StatelessEnforcer) LoginByModel(id string, loginModel *model.Login, ctx
 	if loginModel == nil {
 		return "", errors.New("arg loginModel can not be nil")
 	}
-	token, err := createToken(s.e.GetType(), id, loginModel.Device, loginModel.Timeout, loginModel.JwtData, s.GetSecretKey())
+	token, err := s.createToken(id, loginModel.Device, loginModel.Timeout, loginModel.JwtData)
 	if err != nil {
 		return "", err
 	}
+	return s.responseLogin(id, token, loginModel, ctx)
+}
 
-	err = s.e.ResponseToken(token, loginModel, ctx)
+// responseLogin respond the created token, call logger and watcher
+func (s *StatelessEnforcer) responseLogin(id string, token string, loginModel *model.Login, ctx ctx.Context) (string, error) {
+	if s.stateful {
+		return s.loginSession(id, token, loginModel, ctx)
+	}
+
+	err := s.e.ResponseToken(token, loginModel, ctx)
 	if err != nil {
 		return "", err
 	}
@@ -150,7 +261,7 @@ func (s *StatelessEnforcer) GetClaims(ctx ctx.Context) (jwt.Claims, error) {
 	if token == "" {
 		return nil, errors.New("token is nil")
 	}
-	return s.GetClaimsByToken(token)
+	return s.parseRequestToken(ctx, token)
 }
 
 // GetExtraData get extra data by web context
@@ -159,44 +270,52 @@ func (s *StatelessEnforcer) GetExtraData(ctx ctx.Context, key string) (interface
 	if token == "" {
 		return nil, errors.New("token is nil")
 	}
-	return s.GetExtraDataByToken(token, key)
+	mapClaims, err := s.parseRequestToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return getExtraDataFromClaims(mapClaims, key)
 }
 
 // GetClaimsByToken get token claims
 func (s *StatelessEnforcer) GetClaimsByToken(token string) (jwt.Claims, error) {
-	return parseToken(token, s.GetType(), s.GetSecretKey(), true)
+	return s.parseToken(token, true)
 }
 
 // GetExtraDataByToken parse extraData map
 func (s *StatelessEnforcer) GetExtraDataByToken(token string, key string) (interface{}, error) {
-	mapClaims, err := parseToken(token, s.GetType(), s.GetSecretKey(), true)
+	mapClaims, err := s.parseToken(token, true)
 	if err != nil {
 		return nil, err
 	}
-	extraData := mapClaims[EXTRA_DATA]
-	if extraData == nil {
-		return nil, nil
-	}
-	extraMap, ok := extraData.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid extraData: %v", extraData)
-	}
-	return extraMap[key], nil
+	return getExtraDataFromClaims(mapClaims, key)
 }
 
 func (s *StatelessEnforcer) GetLoginId(ctx ctx.Context) (string, error) {
 	token := s.GetRequestToken(ctx)
-	return s.GetIdByToken(token)
+	payloads, err := s.parseRequestToken(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	return getIdFromClaims(token, payloads)
 }
 
 // GetIdByToken parse token and get id
 func (s *StatelessEnforcer) GetIdByToken(token string) (string, error) {
-	return getId(token, s.GetType(), s.GetSecretKey())
+	payloads, err := s.parseToken(token, true)
+	if err != nil {
+		return "", err
+	}
+	return getIdFromClaims(token, payloads)
 }
 
 // GetTokenTimeout parse and get token timeout
 func (s *StatelessEnforcer) GetTokenTimeout(token string) (int64, error) {
-	timeout, err := getTimeout(token, s.GetType(), s.GetSecretKey())
+	payloads, err := s.parseToken(token, false)
+	if err != nil {
+		return 0, err
+	}
+	timeout, err := calTimeout(token, payloads, s.validator.now())
 	if err != nil {
 		return 0, err
 	}
@@ -206,5 +325,82 @@ func (s *StatelessEnforcer) GetTokenTimeout(token string) (int64, error) {
 // GetTokenTimeoutByCtx similar with GetTokenTimeout
 func (s *StatelessEnforcer) GetTokenTimeoutByCtx(ctx ctx.Context) (int64, error) {
 	token := s.GetRequestToken(ctx)
-	return s.GetTokenTimeout(token)
+	payloads, err := s.verifyRequestToken(ctx, token, false)
+	if err != nil {
+		return 0, err
+	}
+	return calTimeout(token, payloads, s.validator.now())
+}
+
+// parseToken verify token with keys and server side state, return JWT payload
+func (s *StatelessEnforcer) parseToken(token string, isCheckTimeout bool) (jwt.MapClaims, error) {
+	payloads, err := s.parseTokenByKey(token, s.GetType(), isCheckTimeout)
+	if err != nil {
+		return nil, err
+	}
+	err = s.checkRevoked(payloads)
+	if err != nil {
+		return nil, err
+	}
+	err = s.checkVersion(payloads)
+	if err != nil {
+		return nil, err
+	}
+	if s.stateful {
+		err = s.checkSession(token, payloads)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return payloads, nil
+}
+
+// createToken create token with the claims layout of ClaimsOptions
+func (s *StatelessEnforcer) createToken(id string, device string, timeout int64, extraData map[string]interface{}) (string, error) {
+	claims, err := s.newClaims(id, device, timeout, extraData)
+	if err != nil {
+		return "", err
+	}
+	return s.generateToken(claims)
+}
+
+// newClaims create JWT payload with the claims layout of ClaimsOptions
+func (s *StatelessEnforcer) newClaims(id string, device string, timeout int64, extraData map[string]interface{}) (jwt.MapClaims, error) {
+	now := s.validator.now()
+	var claims jwt.MapClaims
+	var err error
+	if s.claimsOptions.Mode == RegisteredClaims {
+		claims, err = newRegisteredClaims(s.GetType(), id, device, timeout, extraData, s.claimsOptions, now)
+	} else {
+		claims, err = newClaims(s.GetType(), id, device, timeout, extraData, now)
+		if err == nil {
+			addIssuerAudience(claims, s.claimsOptions)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.addAuthClaims(claims, id)
+	if s.isAdapterEnabled() {
+		version, err := s.currentVersion(id)
+		if err != nil {
+			return nil, err
+		}
+		claims[VERSION] = version
+	}
+	// login time is used to limit the max lifetime of renewal
+	if s.renewalOptions.Threshold > 0 && s.renewalOptions.MaxLifetime > 0 {
+		claims[AUTH_TIME] = now.Unix()
+	}
+	return claims, nil
+}
+
+// isAdapterEnabled persist.EmptyAdapter can not store data
+func (s *StatelessEnforcer) isAdapterEnabled() bool {
+	adapter := s.GetAdapter()
+	if adapter == nil {
+		return false
+	}
+	_, isEmpty := adapter.(*persist.EmptyAdapter)
+	return !isEmpty
 }
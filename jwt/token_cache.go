@@ -0,0 +1,181 @@
+package jwt
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"github.com/golang-jwt/jwt/v5"
+	"sync"
+	"time"
+)
+
+// TokenCacheOptions verified token cache, signature verification and JSON decoding are skipped on cache hit.
+// Expiration and revocation are still checked on every parse
+type TokenCacheOptions struct {
+	// Size max number of cached tokens, the least recently used token is evicted. Cache is disabled if Size <= 0
+	Size int
+	// Timeout how long a verified token is cached, default is 1 minute.
+	// Removing a key from KeyRing or rotating the key of KeyProvider takes effect on cached tokens after Timeout
+	Timeout time.Duration
+}
+
+func initTokenCacheOptions(options *TokenCacheOptions) {
+	if options.Timeout <= 0 {
+		options.Timeout = time.Minute
+	}
+}
+
+type tokenCacheEntry struct {
+	key        [sha256.Size]byte
+	loginType  string
+	payloads   jwt.MapClaims
+	expireTime time.Time
+}
+
+// tokenCache bounded LRU cache maps the sha256 of token to its verified claims
+type tokenCache struct {
+	mu      sync.Mutex
+	options TokenCacheOptions
+	ll      *list.List
+	entries map[[sha256.Size]byte]*list.Element
+}
+
+func newTokenCache(options TokenCacheOptions) *tokenCache {
+	return &tokenCache{
+		options: options,
+		ll:      list.New(),
+		entries: make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+// get return a copy of cached claims, ok is false if token is not cached or the entry is expired
+func (c *tokenCache) get(token string, loginType string, now time.Time) (jwt.MapClaims, bool) {
+	if c == nil {
+		return nil, false
+	}
+	key := sha256.Sum256([]byte(token))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*tokenCacheEntry)
+	if entry.loginType != loginType {
+		return nil, false
+	}
+	if now.After(entry.expireTime) {
+		c.removeElement(element)
+		return nil, false
+	}
+	c.ll.MoveToFront(element)
+	return copyClaims(entry.payloads), true
+}
+
+func (c *tokenCache) set(token string, loginType string, payloads jwt.MapClaims, now time.Time) {
+	if c == nil {
+		return
+	}
+	key := sha256.Sum256([]byte(token))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.removeElement(element)
+	}
+	c.entries[key] = c.ll.PushFront(&tokenCacheEntry{
+		key:        key,
+		loginType:  loginType,
+		payloads:   copyClaims(payloads),
+		expireTime: now.Add(c.options.Timeout),
+	})
+	for c.ll.Len() > c.options.Size {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+func (c *tokenCache) remove(token string) {
+	if c == nil {
+		return
+	}
+	key := sha256.Sum256([]byte(token))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.removeElement(element)
+	}
+}
+
+// clear remove all entries, called when keys or validation options are changed
+func (c *tokenCache) clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.entries = make(map[[sha256.Size]byte]*list.Element)
+}
+
+func (c *tokenCache) len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *tokenCache) removeElement(element *list.Element) {
+	c.ll.Remove(element)
+	delete(c.entries, element.Value.(*tokenCacheEntry).key)
+}
+
+// copyClaims deep copy claims, so the cached claims, including nested extraData, act and cnf, are not changed by caller
+func copyClaims(payloads jwt.MapClaims) jwt.MapClaims {
+	claims := make(jwt.MapClaims, len(payloads))
+	for k, v := range payloads {
+		claims[k] = copyValue(v)
+	}
+	return claims
+}
+
+// copyValue deep copy the maps and slices decoded from JSON
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[k] = copyValue(item)
+		}
+		return m
+	case jwt.MapClaims:
+		return copyClaims(v)
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, item := range v {
+			s[i] = copyValue(item)
+		}
+		return s
+	case []string:
+		return append([]string(nil), v...)
+	default:
+		return v
+	}
+}
+
+// SetTokenCacheOptions enable the verified token cache, disable it if Size <= 0
+func (s *StatelessEnforcer) SetTokenCacheOptions(options TokenCacheOptions) {
+	if options.Size <= 0 {
+		s.tokenCache = nil
+		return
+	}
+	initTokenCacheOptions(&options)
+	s.tokenCache = newTokenCache(options)
+}
+
+// GetTokenCacheOptions get the verified token cache options, Size is 0 if cache is disabled
+func (s *StatelessEnforcer) GetTokenCacheOptions() TokenCacheOptions {
+	if s.tokenCache == nil {
+		return TokenCacheOptions{}
+	}
+	return s.tokenCache.options
+}
@@ -0,0 +1,144 @@
+package jwt
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/weloe/token-go/model"
+	"testing"
+	"time"
+)
+
+func TestStatelessEnforcer_TokenCache(t *testing.T) {
+	enforcer := newTestAdapterEnforcer(t)
+	clock := &testClock{now: time.Now()}
+	enforcer.SetClock(clock.Now)
+	enforcer.SetTokenCacheOptions(TokenCacheOptions{Size: 2})
+	if options := enforcer.GetTokenCacheOptions(); options.Size != 2 || options.Timeout != time.Minute {
+		t.Errorf("GetTokenCacheOptions() = %+v", options)
+	}
+
+	token, err := enforcer.LoginByModel("1", &model.Login{Timeout: 30, JwtData: map[string]interface{}{"name": "weloe"}}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	if id, err := enforcer.GetIdByToken(token); err != nil || id != "1" {
+		t.Errorf("GetIdByToken() failed: id = %v, err = %v", id, err)
+	}
+	if enforcer.tokenCache.len() != 1 {
+		t.Errorf("token should be cached")
+	}
+	// cached claims can not be changed by caller
+	claims, _ := enforcer.GetClaimsByToken(token)
+	claims.(jwt.MapClaims)[EXTRA_DATA].(map[string]interface{})["name"] = "changed"
+	if data, err := enforcer.GetExtraDataByToken(token, "name"); err != nil || data != "weloe" {
+		t.Errorf("GetExtraDataByToken() failed: data = %v, err = %v", data, err)
+	}
+	delete(claims.(jwt.MapClaims), EXTRA_DATA)
+	if data, err := enforcer.GetExtraDataByToken(token, "name"); err != nil || data != "weloe" {
+		t.Errorf("GetExtraDataByToken() failed: data = %v, err = %v", data, err)
+	}
+
+	// expiration is checked on cache hit
+	clock.Add(31 * time.Second)
+	if _, err = enforcer.GetIdByToken(token); !errors.Is(err, ErrExpired) {
+		t.Errorf("GetIdByToken() error = %v, want ErrExpired", err)
+	}
+	if timeout, err := enforcer.GetTokenTimeout(token); err != nil || timeout != NOT_VALUE_EXPIRE {
+		t.Errorf("GetTokenTimeout() failed: timeout = %v, err = %v", timeout, err)
+	}
+
+	// revocation is checked on cache hit
+	token, _ = enforcer.Login("1", nil)
+	if _, err = enforcer.GetIdByToken(token); err != nil {
+		t.Fatalf("GetIdByToken() failed: %v", err)
+	}
+	if err = enforcer.KickoutAll("1"); err != nil {
+		t.Fatalf("KickoutAll() failed: %v", err)
+	}
+	if _, err = enforcer.GetIdByToken(token); err == nil {
+		t.Errorf("GetIdByToken() should reject kicked out token")
+	}
+	token, _ = enforcer.Login("1", nil)
+	_, _ = enforcer.GetIdByToken(token)
+	if err = enforcer.RevokeToken(token); err != nil {
+		t.Fatalf("RevokeToken() failed: %v", err)
+	}
+	if _, err = enforcer.GetIdByToken(token); !errors.Is(err, ErrRevoked) {
+		t.Errorf("GetIdByToken() error = %v, want ErrRevoked", err)
+	}
+
+	// login type is part of cache key
+	token, _ = enforcer.Login("1", nil)
+	_, _ = enforcer.GetIdByToken(token)
+	enforcer.SetType("admin")
+	if _, err = enforcer.GetIdByToken(token); !errors.Is(err, ErrLoginTypeMismatch) {
+		t.Errorf("GetIdByToken() error = %v, want ErrLoginTypeMismatch", err)
+	}
+	enforcer.SetType("user")
+
+	// changing key clears cache
+	enforcer.SetSecretKey("456")
+	if _, err = enforcer.GetIdByToken(token); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("GetIdByToken() error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestTokenCache_Evict(t *testing.T) {
+	now := time.Now()
+	cache := newTokenCache(TokenCacheOptions{Size: 2, Timeout: time.Second})
+	cache.set("a", "user", map[string]interface{}{LOGIN_ID: "a"}, now)
+	cache.set("b", "user", map[string]interface{}{LOGIN_ID: "b"}, now)
+	if _, ok := cache.get("a", "user", now); !ok {
+		t.Errorf("get() a should be cached")
+	}
+	// b is the least recently used
+	cache.set("c", "user", map[string]interface{}{LOGIN_ID: "c"}, now)
+	if _, ok := cache.get("b", "user", now); ok {
+		t.Errorf("get() b should be evicted")
+	}
+	if _, ok := cache.get("a", "user", now); !ok {
+		t.Errorf("get() a should be cached")
+	}
+	if _, ok := cache.get("c", "user", now.Add(2*time.Second)); ok {
+		t.Errorf("get() c should expire")
+	}
+	if cache.len() != 1 {
+		t.Errorf("len() = %v, want 1", cache.len())
+	}
+}
+
+func benchmarkAccessors(b *testing.B, options TokenCacheOptions) {
+	enforcer, err := NewEnforcer()
+	if err != nil {
+		b.Fatalf("NewEnforcer() failed: %v", err)
+	}
+	enforcer.SetSecretKey("123")
+	enforcer.SetTokenCacheOptions(options)
+	token, err := enforcer.LoginByModel("1", &model.Login{Timeout: 600, JwtData: map[string]interface{}{"name": "weloe"}}, nil)
+	if err != nil {
+		b.Fatalf("LoginByModel() failed: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// a request which calls three accessors
+		if _, err = enforcer.GetIdByToken(token); err != nil {
+			b.Fatal(err)
+		}
+		if _, err = enforcer.GetExtraDataByToken(token, "name"); err != nil {
+			b.Fatal(err)
+		}
+		if _, err = enforcer.GetTokenTimeout(token); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStatelessEnforcer_Accessors(b *testing.B) {
+	benchmarkAccessors(b, TokenCacheOptions{})
+}
+
+func BenchmarkStatelessEnforcer_AccessorsWithTokenCache(b *testing.B) {
+	benchmarkAccessors(b, TokenCacheOptions{Size: 1024})
+}
@@ -0,0 +1,132 @@
+package jwt
+
+import (
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"time"
+)
+
+// ParserOptions golang-jwt parser and validator options.
+// Issuer and audience are verified by ClaimsOptions, leeway is set by SetLeeway
+type ParserOptions struct {
+	// ValidMethods allowed signing algorithms, such as []string{"RS256", "ES256"}.
+	// The algorithm must always match the verifying key selected by kid
+	ValidMethods []string
+	// RequiredClaims claims that must be present in token, such as exp, iat, jti
+	RequiredClaims []string
+	// ValidatorOptions extra golang-jwt validator options applied to registered layout token,
+	// such as jwt.WithSubject, jwt.WithIssuedAt, jwt.WithExpirationRequired
+	ValidatorOptions []jwt.ParserOption
+}
+
+// validator verify claims with a clock, leeway, issuer, audience and parser options
+type validator struct {
+	clock Clock
+	// leeway tolerance of exp, eff, nbf and iat for clock skew
+	leeway        time.Duration
+	issuer        string
+	audience      []string
+	allowLegacy   bool
+	parserOptions ParserOptions
+}
+
+var defaultValidator = &validator{clock: time.Now}
+
+func (v *validator) now() time.Time {
+	if v == nil || v.clock == nil {
+		return time.Now()
+	}
+	return v.clock()
+}
+
+func (v *validator) newParser() *jwt.Parser {
+	options := []jwt.ParserOption{jwt.WithoutClaimsValidation()}
+	if v != nil && len(v.parserOptions.ValidMethods) > 0 {
+		options = append(options, jwt.WithValidMethods(v.parserOptions.ValidMethods))
+	}
+	return jwt.NewParser(options...)
+}
+
+// validate verify required claims, time based claims, issuer and audience.
+// If isCheckTimeout is false, expiration time is not verified
+func (v *validator) validate(token string, payloads jwt.MapClaims, isCheckTimeout bool) error {
+	if v == nil {
+		v = defaultValidator
+	}
+	for _, claim := range v.parserOptions.RequiredClaims {
+		if _, ok := payloads[claim]; !ok {
+			return fmt.Errorf("%w: %v", jwt.ErrTokenRequiredClaimMissing, claim)
+		}
+	}
+
+	// legacy layout only has eff claim in milliseconds
+	if !isRegisteredLayout(payloads) {
+		if isCheckTimeout {
+			eff, ok := claimExpiration(payloads)
+			if !ok {
+				return fmt.Errorf("%w: invalid eff", jwt.ErrTokenInvalidClaims)
+			}
+			if eff > NEVER_EXPIRE && eff+v.leeway.Milliseconds() < v.now().UnixMilli() {
+				return jwt.ErrTokenExpired
+			}
+		}
+		if v.allowLegacy {
+			return nil
+		}
+		return v.validateIssuerAudience(payloads)
+	}
+
+	claims := payloads
+	if !isCheckTimeout {
+		// verify registered claims except exp
+		claims = make(jwt.MapClaims, len(payloads))
+		for k, value := range payloads {
+			if k != EXPIRES_AT {
+				claims[k] = value
+			}
+		}
+	}
+	options := []jwt.ParserOption{jwt.WithLeeway(v.leeway), jwt.WithTimeFunc(v.now)}
+	options = append(options, v.parserOptions.ValidatorOptions...)
+	err := jwt.NewValidator(options...).Validate(claims)
+	if err != nil {
+		return err
+	}
+	return v.validateIssuerAudience(payloads)
+}
+
+// validateIssuerAudience verify iss and aud claims if they are configured
+func (v *validator) validateIssuerAudience(payloads jwt.MapClaims) error {
+	if v.issuer != "" {
+		iss, err := payloads.GetIssuer()
+		if err != nil || iss != v.issuer {
+			return jwt.ErrTokenInvalidIssuer
+		}
+	}
+	if len(v.audience) > 0 {
+		aud, err := payloads.GetAudience()
+		if err != nil {
+			return jwt.ErrTokenInvalidAudience
+		}
+		for _, expected := range v.audience {
+			for _, actual := range aud {
+				if expected == actual {
+					return nil
+				}
+			}
+		}
+		return jwt.ErrTokenInvalidAudience
+	}
+	return nil
+}
+
+// SetParserOptions set golang-jwt parser and validator options
+func (s *StatelessEnforcer) SetParserOptions(options ParserOptions) {
+	s.validator.parserOptions = options
+	s.tokenCache.clear()
+}
+
+// GetParserOptions get golang-jwt parser and validator options
+func (s *StatelessEnforcer) GetParserOptions() ParserOptions {
+	return s.validator.parserOptions
+}
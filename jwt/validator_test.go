@@ -0,0 +1,70 @@
+package jwt
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/weloe/token-go/model"
+	"testing"
+	"time"
+)
+
+func TestValidator_ErrTokenExpired(t *testing.T) {
+	for _, mode := range []ClaimsMode{LegacyClaims, RegisteredClaims} {
+		enforcer, clock := newTestClockEnforcer(t, mode)
+		token, err := enforcer.LoginByModel("1", &model.Login{Timeout: 60}, nil)
+		if err != nil {
+			t.Fatalf("LoginByModel() failed: %v", err)
+		}
+		clock.Add(61 * time.Second)
+		if _, err = enforcer.GetIdByToken(token); !errors.Is(err, jwt.ErrTokenExpired) {
+			t.Errorf("mode %v GetIdByToken() error = %v, want ErrTokenExpired", mode, err)
+		}
+	}
+}
+
+func TestValidator_IssuerAudience(t *testing.T) {
+	enforcer := newTestRegisteredEnforcer(t, ClaimsOptions{Issuer: "token-go", Audience: []string{"api"}})
+	token, err := enforcer.LoginByModel("1", &model.Login{Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+
+	other := newTestRegisteredEnforcer(t, ClaimsOptions{Issuer: "other"})
+	if _, err = other.GetIdByToken(token); !errors.Is(err, jwt.ErrTokenInvalidIssuer) {
+		t.Errorf("GetIdByToken() error = %v, want ErrTokenInvalidIssuer", err)
+	}
+	other = newTestRegisteredEnforcer(t, ClaimsOptions{Audience: []string{"admin"}})
+	if _, err = other.GetIdByToken(token); !errors.Is(err, jwt.ErrTokenInvalidAudience) {
+		t.Errorf("GetIdByToken() error = %v, want ErrTokenInvalidAudience", err)
+	}
+}
+
+func TestStatelessEnforcer_SetParserOptions(t *testing.T) {
+	enforcer := newTestRegisteredEnforcer(t, ClaimsOptions{})
+	token, err := enforcer.LoginByModel("1", &model.Login{Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+
+	enforcer.SetParserOptions(ParserOptions{ValidMethods: []string{"RS256"}})
+	if _, err = enforcer.GetIdByToken(token); !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+		t.Errorf("GetIdByToken() error = %v, want ErrTokenSignatureInvalid", err)
+	}
+	enforcer.SetParserOptions(ParserOptions{ValidMethods: []string{"HS256"}})
+	if _, err = enforcer.GetIdByToken(token); err != nil {
+		t.Errorf("GetIdByToken() failed: %v", err)
+	}
+
+	enforcer.SetParserOptions(ParserOptions{RequiredClaims: []string{"scope"}})
+	if _, err = enforcer.GetIdByToken(token); !errors.Is(err, jwt.ErrTokenRequiredClaimMissing) {
+		t.Errorf("GetIdByToken() error = %v, want ErrTokenRequiredClaimMissing", err)
+	}
+
+	enforcer.SetParserOptions(ParserOptions{ValidatorOptions: []jwt.ParserOption{jwt.WithSubject("2")}})
+	if _, err = enforcer.GetIdByToken(token); !errors.Is(err, jwt.ErrTokenInvalidSubject) {
+		t.Errorf("GetIdByToken() error = %v, want ErrTokenInvalidSubject", err)
+	}
+	if enforcer.GetParserOptions().ValidatorOptions == nil {
+		t.Errorf("GetParserOptions() should return options")
+	}
+}
@@ -0,0 +1,239 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	tokenErrors "github.com/weloe/token-go/errors"
+	"github.com/weloe/token-go/util"
+	"sync"
+	"time"
+)
+
+// VERSION token version claim, token whose version is less than the kickout version of loginId is rejected
+const VERSION = "ver"
+
+const (
+	defaultVersionCacheTimeout = time.Second
+	maxVersionCacheSize        = 1024
+	// versionLockTimeout seconds, the lock expires if the holder crashes
+	versionLockTimeout = 5
+	versionLockRetry   = 100
+	versionLockWait    = 50 * time.Millisecond
+)
+
+// versionRecord token versions of loginId.
+// Seq increases on every kickout, token is issued with the current Seq
+type versionRecord struct {
+	Seq int64 `json:"seq"`
+	// All tokens of loginId whose version < All are kicked out
+	All int64 `json:"all"`
+	// Devices tokens of device whose version < Devices[device] are kicked out
+	Devices map[string]int64 `json:"devices,omitempty"`
+}
+
+// isKicked check if the token of version and device is kicked out
+func (r *versionRecord) isKicked(version int64, device string) bool {
+	return version < r.All || version < r.Devices[device]
+}
+
+type versionCacheEntry struct {
+	record     *versionRecord
+	expireTime time.Time
+}
+
+// versionCache local cache of versionRecord, avoid reading adapter on every verification
+type versionCache struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	entries map[string]*versionCacheEntry
+}
+
+func newVersionCache(timeout time.Duration) *versionCache {
+	return &versionCache{
+		timeout: timeout,
+		entries: make(map[string]*versionCacheEntry),
+	}
+}
+
+func (c *versionCache) get(key string, now time.Time) (*versionRecord, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || now.After(entry.expireTime) {
+		return nil, false
+	}
+	return entry.record, true
+}
+
+func (c *versionCache) set(key string, record *versionRecord, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.timeout <= 0 {
+		return
+	}
+	if len(c.entries) >= maxVersionCacheSize {
+		c.entries = make(map[string]*versionCacheEntry)
+	}
+	c.entries[key] = &versionCacheEntry{record: record, expireTime: now.Add(c.timeout)}
+}
+
+func (c *versionCache) setTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timeout = timeout
+	c.entries = make(map[string]*versionCacheEntry)
+}
+
+// SetVersionCacheTimeout set how long token version is cached locally, default is 1 second.
+// Kickout on other nodes takes effect after the cache expires, cache is disabled if timeout <= 0
+func (s *StatelessEnforcer) SetVersionCacheTimeout(timeout time.Duration) {
+	s.versionCache.setTimeout(timeout)
+}
+
+// KickoutAll kick out all tokens of loginId, parsing them returns errors.BeKicked
+func (s *StatelessEnforcer) KickoutAll(id string) error {
+	return s.bumpVersion(id, "")
+}
+
+// KickoutDevice kick out all tokens of loginId and device, parsing them returns errors.BeKicked
+func (s *StatelessEnforcer) KickoutDevice(id string, device string) error {
+	if device == "" {
+		return errors.New("arg device can not be nil")
+	}
+	return s.bumpVersion(id, device)
+}
+
+// bumpVersion increase Seq of loginId, and set it as the kickout version of all tokens or device.
+// The record is updated under a lock in adapter, so concurrent kickout on other nodes is not lost
+func (s *StatelessEnforcer) bumpVersion(id string, device string) error {
+	if id == "" {
+		return errors.New("arg id can not be nil")
+	}
+	if !s.isAdapterEnabled() {
+		return errors.New("kickout by loginId needs a persist.Adapter, please call SetAdapter()")
+	}
+	unlock, err := s.lockVersion(id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	record, err := s.loadVersion(id)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		record = &versionRecord{}
+	}
+	record.Seq++
+	if device == "" {
+		record.All = record.Seq
+	} else {
+		if record.Devices == nil {
+			record.Devices = make(map[string]int64)
+		}
+		record.Devices[device] = record.Seq
+	}
+
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	err = s.GetAdapter().SetStr(s.spliceVersionKey(id), string(bytes), NEVER_EXPIRE)
+	if err != nil {
+		return err
+	}
+	s.versionCache.set(id, record, s.validator.now())
+
+	s.e.GetLogger().Kickout(s.GetType(), id, "")
+
+	if s.e.GetWatcher() != nil {
+		s.e.GetWatcher().Kickout(s.GetType(), id, "")
+	}
+	return nil
+}
+
+// lockVersion acquire the lock of versionRecord of loginId by setStrIfAbsent, return the unlock function
+func (s *StatelessEnforcer) lockVersion(id string) (func(), error) {
+	key := s.spliceVersionKey(id) + ":lock"
+	owner, err := util.GenerateRandomString32()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < versionLockRetry; i++ {
+		ok, err := s.setStrIfAbsent(key, owner, versionLockTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return func() {
+				// the lock may have expired and been acquired by others
+				if s.GetAdapter().GetStr(key) == owner {
+					_ = s.GetAdapter().DeleteStr(key)
+				}
+			}, nil
+		}
+		time.Sleep(versionLockWait)
+	}
+	return nil, errors.New("kickout failed: the version record of loginId is locked")
+}
+
+// currentVersion read the latest Seq of loginId from adapter, used to issue token
+func (s *StatelessEnforcer) currentVersion(id string) (int64, error) {
+	record, err := s.loadVersion(id)
+	if err != nil || record == nil {
+		return 0, err
+	}
+	s.versionCache.set(id, record, s.validator.now())
+	return record.Seq, nil
+}
+
+// checkVersion return errors.BeKicked if the token version is stale, skip if adapter is persist.EmptyAdapter
+func (s *StatelessEnforcer) checkVersion(payloads jwt.MapClaims) error {
+	if !s.isAdapterEnabled() {
+		return nil
+	}
+	id, ok := claimLoginId(payloads)
+	if !ok {
+		return nil
+	}
+	now := s.validator.now()
+	record, ok := s.versionCache.get(id, now)
+	if !ok {
+		var err error
+		record, err = s.loadVersion(id)
+		if err != nil {
+			return err
+		}
+		s.versionCache.set(id, record, now)
+	}
+	if record == nil {
+		return nil
+	}
+	// token created before versioning has version 0
+	version, _ := payloads[VERSION].(float64)
+	device, _ := payloads[DEVICE].(string)
+	if record.isKicked(int64(version), device) {
+		return tokenErrors.BeKicked
+	}
+	return nil
+}
+
+// loadVersion read versionRecord of loginId from adapter, return nil if not exist
+func (s *StatelessEnforcer) loadVersion(id string) (*versionRecord, error) {
+	value := s.GetAdapter().GetStr(s.spliceVersionKey(id))
+	if value == "" {
+		return nil, nil
+	}
+	record := &versionRecord{}
+	err := json.Unmarshal([]byte(value), record)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// spliceVersionKey splice token version key
+func (s *StatelessEnforcer) spliceVersionKey(id string) string {
+	return s.GetTokenConfig().TokenName + ":" + s.GetType() + ":ver:" + id
+}
@@ -0,0 +1,179 @@
+package jwt
+
+import (
+	"errors"
+	tokenErrors "github.com/weloe/token-go/errors"
+	"github.com/weloe/token-go/model"
+	"github.com/weloe/token-go/persist"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStatelessEnforcer_KickoutAll(t *testing.T) {
+	enforcer := newTestAdapterEnforcer(t)
+
+	pc, err := enforcer.LoginByModel("1", &model.Login{Device: "pc", Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	app, err := enforcer.LoginByModel("1", &model.Login{Device: "app", Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	other, err := enforcer.LoginByModel("2", &model.Login{Device: "pc", Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	// token created before versioning
+	legacy, err := createToken("user", "1", "pc", 60, nil, "123")
+	if err != nil {
+		t.Fatalf("createToken() failed: %v", err)
+	}
+
+	err = enforcer.KickoutAll("1")
+	if err != nil {
+		t.Fatalf("KickoutAll() failed: %v", err)
+	}
+	for _, token := range []string{pc, app, legacy} {
+		if _, err = enforcer.GetIdByToken(token); !errors.Is(err, tokenErrors.BeKicked) {
+			t.Errorf("GetIdByToken() error = %v, want BeKicked", err)
+		}
+	}
+	if _, err = enforcer.GetIdByToken(other); err != nil {
+		t.Errorf("GetIdByToken() other loginId failed: %v", err)
+	}
+
+	token, err := enforcer.LoginByModel("1", &model.Login{Device: "pc", Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	if id, err := enforcer.GetIdByToken(token); err != nil || id != "1" {
+		t.Errorf("GetIdByToken() new token failed: id = %v, err = %v", id, err)
+	}
+}
+
+func TestStatelessEnforcer_KickoutDevice(t *testing.T) {
+	enforcer := newTestAdapterEnforcer(t)
+
+	pc, err := enforcer.LoginByModel("1", &model.Login{Device: "pc", Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	app, err := enforcer.LoginByModel("1", &model.Login{Device: "app", Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+
+	if err = enforcer.KickoutDevice("1", ""); err == nil {
+		t.Errorf("KickoutDevice() should reject empty device")
+	}
+	err = enforcer.KickoutDevice("1", "pc")
+	if err != nil {
+		t.Fatalf("KickoutDevice() failed: %v", err)
+	}
+	if _, err = enforcer.GetIdByToken(pc); !errors.Is(err, tokenErrors.BeKicked) {
+		t.Errorf("GetIdByToken() error = %v, want BeKicked", err)
+	}
+	if _, err = enforcer.GetIdByToken(app); err != nil {
+		t.Errorf("GetIdByToken() other device failed: %v", err)
+	}
+
+	newPc, err := enforcer.LoginByModel("1", &model.Login{Device: "pc", Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	err = enforcer.KickoutDevice("1", "app")
+	if err != nil {
+		t.Fatalf("KickoutDevice() failed: %v", err)
+	}
+	if _, err = enforcer.GetIdByToken(newPc); err != nil {
+		t.Errorf("GetIdByToken() new pc token failed: %v", err)
+	}
+	if _, err = enforcer.GetIdByToken(app); !errors.Is(err, tokenErrors.BeKicked) {
+		t.Errorf("GetIdByToken() error = %v, want BeKicked", err)
+	}
+}
+
+func TestStatelessEnforcer_KickoutAllRefreshToken(t *testing.T) {
+	enforcer := newTestAdapterEnforcer(t)
+
+	pair, err := enforcer.LoginWithRefreshToken("1", &model.Login{Timeout: 60}, 120, nil)
+	if err != nil {
+		t.Fatalf("LoginWithRefreshToken() failed: %v", err)
+	}
+	err = enforcer.KickoutAll("1")
+	if err != nil {
+		t.Fatalf("KickoutAll() failed: %v", err)
+	}
+	if _, err = enforcer.Refresh(pair.RefreshToken); !errors.Is(err, tokenErrors.BeKicked) {
+		t.Errorf("Refresh() error = %v, want BeKicked", err)
+	}
+}
+
+func TestStatelessEnforcer_VersionCache(t *testing.T) {
+	enforcer := newTestAdapterEnforcer(t)
+	clock := &testClock{now: time.Now()}
+	enforcer.SetClock(clock.Now)
+
+	// another node shares the adapter
+	node, err := NewEnforcer()
+	if err != nil {
+		t.Fatalf("NewEnforcer() failed: %v", err)
+	}
+	node.SetSecretKey("123")
+	node.SetAdapter(enforcer.GetAdapter())
+	node.SetClock(clock.Now)
+
+	token, err := enforcer.LoginByModel("1", &model.Login{Timeout: 60}, nil)
+	if err != nil {
+		t.Fatalf("LoginByModel() failed: %v", err)
+	}
+	if _, err = node.GetIdByToken(token); err != nil {
+		t.Fatalf("GetIdByToken() failed: %v", err)
+	}
+
+	err = enforcer.KickoutAll("1")
+	if err != nil {
+		t.Fatalf("KickoutAll() failed: %v", err)
+	}
+	// cached version is used until cache expires
+	if _, err = node.GetIdByToken(token); err != nil {
+		t.Errorf("GetIdByToken() should use cached version: %v", err)
+	}
+	clock.Add(2 * time.Second)
+	if _, err = node.GetIdByToken(token); !errors.Is(err, tokenErrors.BeKicked) {
+		t.Errorf("GetIdByToken() error = %v, want BeKicked", err)
+	}
+}
+
+func TestStatelessEnforcer_KickoutConcurrently(t *testing.T) {
+	// two nodes share one adapter
+	adapter := &testAtomicAdapter{DefaultAdapter: persist.NewDefaultAdapter()}
+	nodes := []*StatelessEnforcer{newTestEnforcer(t), newTestEnforcer(t)}
+	for _, node := range nodes {
+		node.SetSecretKey("123")
+		node.SetAdapter(adapter)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := nodes[i%2].KickoutDevice("1", "device-"+strconv.Itoa(i)); err != nil {
+				t.Errorf("KickoutDevice() failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	record, err := nodes[0].loadVersion("1")
+	if err != nil || record == nil {
+		t.Fatalf("loadVersion() failed: record = %v, err = %v", record, err)
+	}
+	if record.Seq != 10 || len(record.Devices) != 10 {
+		t.Errorf("concurrent kickout is lost: record = %+v", record)
+	}
+}
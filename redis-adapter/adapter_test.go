@@ -236,3 +236,21 @@ func TestEnforcer(t *testing.T) {
 	}
 
 }
+
+func TestRedisAdapter_SetStrIfAbsent(t *testing.T) {
+	adapter := newTestRedisAdapter(t).(*RedisAdapter)
+	_ = adapter.DeleteStr("k_absent")
+
+	ok, err := adapter.SetStrIfAbsent("k_absent", "v1", 10)
+	if err != nil || !ok {
+		t.Fatalf("SetStrIfAbsent() failed: ok = %v, err = %v", ok, err)
+	}
+	ok, err = adapter.SetStrIfAbsent("k_absent", "v2", 10)
+	if err != nil || ok {
+		t.Fatalf("SetStrIfAbsent() failed: key exists, ok = %v, err = %v", ok, err)
+	}
+	if v := adapter.GetStr("k_absent"); v != "v1" {
+		t.Fatalf("GetStr() failed: value is %v, want v1", v)
+	}
+	_ = adapter.DeleteStr("k_absent")
+}
@@ -72,6 +72,15 @@ func (r *ClusterAdapter) SetStr(key string, value string, timeout int64) error {
 	return nil
 }
 
+// SetStrIfAbsent set value only if key does not exist by SETNX, return false if key exists
+func (r *ClusterAdapter) SetStrIfAbsent(key string, value string, timeout int64) (bool, error) {
+	var duration time.Duration
+	if timeout > 0 {
+		duration = time.Duration(timeout) * time.Second
+	}
+	return r.client.SetNX(context.Background(), key, value, duration).Result()
+}
+
 func (r *ClusterAdapter) UpdateStr(key string, value string) error {
 	err := r.client.Set(context.Background(), key, value, 0).Err()
 	if err != nil {
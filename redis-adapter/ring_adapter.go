@@ -55,6 +55,15 @@ func (r *RingAdapter) SetStr(key string, value string, timeout int64) error {
 	return nil
 }
 
+// SetStrIfAbsent set value only if key does not exist by SETNX, return false if key exists
+func (r *RingAdapter) SetStrIfAbsent(key string, value string, timeout int64) (bool, error) {
+	var duration time.Duration
+	if timeout > 0 {
+		duration = time.Duration(timeout) * time.Second
+	}
+	return r.client.SetNX(context.Background(), key, value, duration).Result()
+}
+
 func (r *RingAdapter) UpdateStr(key string, value string) error {
 	err := r.client.Set(context.Background(), key, value, 0).Err()
 	if err != nil {